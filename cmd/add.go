@@ -17,37 +17,39 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 
-	"github.com/liamawhite/worktree/pkg/worktree"
 	"github.com/spf13/cobra"
 )
 
 var addCmd = &cobra.Command{
 	Use:   "add <worktree-name>",
 	Short: "Add a new worktree",
-	Long:  `Create a new worktree with a new branch based on the specified base branch.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new worktree with a new branch based on the specified base branch, or the base branch
+recorded by setup (main or master when none was recorded).`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		branch := args[0]
 		base, _ := cmd.Flags().GetString("base")
 
-		wm, err := worktree.NewWorktreeManager()
+		wm, err := newWorktreeManager()
 		if err != nil {
 			return err
 		}
 
+		if base == "" {
+			base = wm.BaseBranch()
+		}
 		fmt.Printf("Creating worktree and branch: %s from base: %s\n", branch, base)
-		if err := wm.AddWorktree(branch, base); err != nil {
+		if err := wm.AddWorktree(os.Stdout, branch, base); err != nil {
 			return err
 		}
 
-		worktreePath := filepath.Join(wm.GitRoot, branch)
+		worktreePath := wm.WorktreePath(branch)
 		fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", worktreePath)
 		return nil
 	},
 }
 
 func init() {
-	addCmd.Flags().StringP("base", "b", "main", "Base branch to create the new worktree from")
+	addCmd.Flags().StringP("base", "b", "", "Base branch to create the new worktree from (defaults to the repository's base branch)")
 }
@@ -0,0 +1,55 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Convert an existing clone into the worktree layout",
+	Long: `Convert a standard clone in place into the layout created by setup.
+
+The .git directory becomes .bare, and the current checkout, including uncommitted changes,
+becomes the worktree of the checked out branch. All refs, remotes, config and stashes are kept.
+Use --all-branches to also create a worktree for every other local branch.
+If the conversion fails the clone is restored. Repositories with submodules are not supported.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		allBranches, _ := cmd.Flags().GetBool("all-branches")
+
+		worktreePath, err := setup.Adopt(dir, setup.AdoptOptions{AllBranches: allBranches})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Adopted repository, current checkout is now at %s\n", worktreePath)
+		fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", worktreePath)
+		return nil
+	},
+}
+
+func init() {
+	adoptCmd.Flags().Bool("all-branches", false, "Also create a worktree for every other local branch")
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/liamawhite/worktree/pkg/cache"
+	"github.com/liamawhite/worktree/pkg/progress"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Maintain the shared object cache",
+	Long: `Maintain the shared object cache that repositories set up with --cache borrow objects from.
+
+Each upstream is cached once as a bare repository under <cache dir>/<host>/<namespace>/<repo>.git.
+Enable it for every setup with 'wt config set-cache on'.`,
+}
+
+var cacheUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Fetch the latest branches and tags into every cached repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := configuredCache()
+		if err != nil {
+			return err
+		}
+		return withReporter(cmd, c.Update)
+	},
+}
+
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Garbage collect the cache and remove repositories nothing uses",
+	Long: `Garbage collect every cached repository. Repositories that were removed or ejected stop counting
+as users of the cache, objects still borrowed by the remaining ones are kept, and cached repositories
+no longer used by any repository are deleted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := configuredCache()
+		if err != nil {
+			return err
+		}
+		return withReporter(cmd, c.GC)
+	},
+}
+
+// withReporter runs fn with its output rendered by the --progress reporter
+func withReporter(cmd *cobra.Command, fn func(out io.Writer) error) error {
+	reporter, err := newReporter(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reporter.Close() }()
+
+	out := progress.NewWriter(reporter, "")
+	defer out.Flush()
+	return fn(out)
+}
+
+// configuredCache returns the object cache in the configured directory
+func configuredCache() (*cache.Cache, error) {
+	cfg, err := LoadConfigWithOverride()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	dir, err := cfg.Cache.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.New(dir), nil
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheUpdateCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+}
@@ -18,7 +18,6 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/liamawhite/worktree/pkg/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +26,7 @@ var clearCmd = &cobra.Command{
 	Short: "Clear all worktrees except main, master and review",
 	Long:  `Remove all worktrees except main, master and review branches.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		wm, err := worktree.NewWorktreeManager()
+		wm, err := newWorktreeManager()
 		if err != nil {
 			return fmt.Errorf("must be in a git repository to clear worktrees: %w", err)
 		}
@@ -16,9 +16,15 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/setup"
 	"github.com/spf13/cobra"
 )
 
@@ -90,7 +96,23 @@ var listCmd = &cobra.Command{
 			if cloneMethod == "" {
 				cloneMethod = config.CloneMethodHTTP // Default display
 			}
-			fmt.Printf("  %s: %s (clone: %s)\n", domain, hostConfig.Account, cloneMethod)
+			details := "clone: " + string(cloneMethod)
+			if hostConfig.TokenEnv != "" {
+				details += ", token: $" + hostConfig.TokenEnv
+			}
+			if hostConfig.TokenFile != "" {
+				details += ", token file: " + hostConfig.TokenFile
+			}
+			if cloneMethod == config.CloneMethodSSH || hostConfig.SSHUser != "" || hostConfig.SSHHost != "" || hostConfig.IdentityFile != "" {
+				details += ", ssh " + sshDetails(hostConfig)
+			}
+			if identity := identityDetails(hostConfig); identity != "" {
+				details += ", " + identity
+			}
+			fmt.Printf("  %s: %s (%s)\n", domain, hostConfig.Account, details)
+			for _, profile := range hostConfig.Profiles {
+				fmt.Printf("    profile %s: %s\n", profile.Name, profileDetails(profile))
+			}
 		}
 
 		return nil
@@ -132,8 +154,665 @@ Examples:
 	},
 }
 
+var setSelectorCmd = &cobra.Command{
+	Use:   "set-selector <backend>",
+	Short: "Set the interactive selector backend",
+	Long: `Set the backend used to interactively select worktrees.
+
+Backends:
+  auto    Bubble Tea TUI when attached to a terminal, plain prompt otherwise (default)
+  tui     Bubble Tea TUI with filtering and a preview pane
+  fzf     External fzf fuzzy finder
+  sk      External skim fuzzy finder
+  prompt  Plain numbered prompt read from stdin
+
+Examples:
+  wt config set-selector fzf
+  wt config set-selector prompt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		backend, err := config.ParseSelectorBackend(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg.SetSelectorBackend(backend)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("Set selector backend to %s\n", backend)
+		return nil
+	},
+}
+
+var setLayoutCmd = &cobra.Command{
+	Use:   "set-layout <layout> [path-template]",
+	Short: "Set where new worktrees are placed",
+	Long: `Set the layout used to place new worktrees.
+
+Layouts:
+  nested   Inside the repository root, or .worktrees/ for standard clones (default for setup)
+  sibling  In a <repo>.worktrees directory next to the repository
+  custom   At the path rendered from a template using {{.Repo}}, {{.Branch}} and {{.Root}}
+
+Existing worktrees are found wherever git records them, whatever the layout.
+
+Examples:
+  wt config set-layout sibling
+  wt config set-layout custom '~/wt/{{.Repo}}/{{.Branch}}'`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		layout, err := config.ParseWorktreeLayout(args[0])
+		if err != nil {
+			return err
+		}
+
+		var path string
+		if len(args) > 1 {
+			path = args[1]
+		}
+		if layout == config.WorktreeLayoutCustom && path == "" {
+			return fmt.Errorf("the custom layout requires a path template, e.g. '~/wt/{{.Repo}}/{{.Branch}}'")
+		}
+		if layout != config.WorktreeLayoutCustom && path != "" {
+			return fmt.Errorf("a path template can only be used with the custom layout")
+		}
+		if path != "" {
+			if _, err := config.RenderWorktreePath(path, "/repo", "branch"); err != nil {
+				return err
+			}
+		}
+
+		cfg.SetWorktreeLayout(layout, path)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("Set worktree layout to %s\n", layout)
+		return nil
+	},
+}
+
+var setTokenCmd = &cobra.Command{
+	Use:   "set-token <domain>",
+	Short: "Set where the HTTPS access token for a domain is read from",
+	Long: `Set the environment variable or file the access token for HTTPS clones and fetches from a domain
+is read from. The environment variable is used when it is set, otherwise the file. The token is sent
+with the configured account as the username. Without either flag the token settings are removed and
+git's own credential helpers are used.
+
+Repositories set up while a token is configured ask wt for it when fetching, after any credential
+helpers you configured in git. They read it from the config file used when they were set up.
+
+Examples:
+  wt config set-token github.com --env GITHUB_TOKEN
+  wt config set-token gitlab.company.com --file ~/.config/worktree/gitlab-token
+  wt config set-token gitlab.company.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[0]
+		env, _ := cmd.Flags().GetString("env")
+		file, err := configPath(cmd, "file")
+		if err != nil {
+			return err
+		}
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		cfg.SetToken(domain, env, file)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		switch {
+		case env != "" && file != "":
+			fmt.Printf("Reading the token for %s from $%s, or %s when it is unset\n", domain, env, file)
+		case env != "":
+			fmt.Printf("Reading the token for %s from $%s\n", domain, env)
+		case file != "":
+			fmt.Printf("Reading the token for %s from %s\n", domain, file)
+		default:
+			fmt.Printf("Removed the token settings for %s\n", domain)
+		}
+		return nil
+	},
+}
+
+var setSSHCmd = &cobra.Command{
+	Use:   "set-ssh <domain>",
+	Short: "Set the SSH user, port and identity for a domain",
+	Long: `Set how SSH clones and fetches from a domain connect. Only the given flags are changed; pass an
+empty value to remove a setting.
+
+The user and port are used in the SSH URLs setup generates. The identity file is the only key
+offered to the host, so separate work and personal keys don't get mixed up, and it is recorded in
+the repositories set up from the domain so fetches, pulls and pushes use it too. Its passphrase is
+read from an environment variable or a file; keys held by the SSH agent need neither. A pinned host
+key fingerprint lets setup and 'wt ssh trust' add the host to known_hosts without asking, and
+rejects any other key.
+
+Without an identity file the host's ssh_config entry is honored, including Host aliases, and
+otherwise the SSH agent and the default keys in ~/.ssh are tried.
+
+Examples:
+  wt config set-ssh github.com --identity-file ~/.ssh/id_work --passphrase-env WORK_KEY_PASSPHRASE
+  wt config set-ssh bitbucket.company.com --port 7999
+  wt config set-ssh git.company.com --user gitolite
+  wt config set-ssh github.com --host-key-fingerprint SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU
+  wt config set-ssh github.com --identity-file ""`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[0]
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		host := cfg.GetHostConfig(domain)
+		if cmd.Flags().Changed("user") {
+			host.SSHUser, _ = cmd.Flags().GetString("user")
+		}
+		if cmd.Flags().Changed("port") {
+			host.Port, _ = cmd.Flags().GetString("port")
+			if _, err := strconv.Atoi(host.Port); host.Port != "" && err != nil {
+				return fmt.Errorf("invalid port %q", host.Port)
+			}
+		}
+		if cmd.Flags().Changed("identity-file") {
+			if host.IdentityFile, err = configPath(cmd, "identity-file"); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("passphrase-env") {
+			host.PassphraseEnv, _ = cmd.Flags().GetString("passphrase-env")
+		}
+		if cmd.Flags().Changed("passphrase-file") {
+			if host.PassphraseFile, err = configPath(cmd, "passphrase-file"); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("host-key-fingerprint") {
+			host.HostKeyFingerprint, _ = cmd.Flags().GetString("host-key-fingerprint")
+			if host.HostKeyFingerprint != "" && !strings.HasPrefix(host.HostKeyFingerprint, "SHA256:") {
+				return fmt.Errorf("invalid fingerprint %q, expected a SHA256 fingerprint such as SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU", host.HostKeyFingerprint)
+			}
+		}
+		cfg.SetHostConfig(domain, host)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("Set SSH settings for %s (%s)\n", domain, sshDetails(host))
+		return nil
+	},
+}
+
+// configPath returns the path given to the flag, made absolute unless it is relative to the home directory
+func configPath(cmd *cobra.Command, flag string) (string, error) {
+	path, _ := cmd.Flags().GetString(flag)
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	return filepath.Abs(path)
+}
+
+// sshDetails summarises the SSH settings of a host
+func sshDetails(host config.HostConfig) string {
+	user := host.SSHUser
+	if user == "" {
+		user = "git"
+	}
+	details := "user: " + user
+	if host.SSHHost != "" {
+		details += ", host: " + host.SSHHost
+	}
+	if host.Port != "" {
+		details += ", port: " + host.Port
+	}
+	if host.IdentityFile != "" {
+		details += ", identity: " + host.IdentityFile
+	}
+	if host.PassphraseEnv != "" {
+		details += ", passphrase: $" + host.PassphraseEnv
+	}
+	if host.PassphraseFile != "" {
+		details += ", passphrase file: " + host.PassphraseFile
+	}
+	if host.HostKeyFingerprint != "" {
+		details += ", host key: " + host.HostKeyFingerprint
+	}
+	return details
+}
+
+var setIdentityCmd = &cobra.Command{
+	Use:   "set-identity <domain>",
+	Short: "Set the commit identity and signing for a domain",
+	Long: `Set the author name and email commits are made with in repositories set up from a domain, and how
+they are signed. Only the given flags are changed; pass an empty value to remove a setting.
+
+Setup writes the settings into the new repository's config. Run 'wt config apply' to update
+repositories that are already set up. Settings that aren't configured come from your global git config.
+
+The signing key is a GPG key ID with --signing-format gpg, and the path of a key with
+--signing-format ssh. --sign on or off turns commit.gpgSign on or off.
+
+Examples:
+  wt config set-identity github.enterprise.com --name "Jane Doe" --email jane.doe@company.com
+  wt config set-identity github.com --signing-format ssh --signing-key ~/.ssh/id_ed25519.pub --sign on
+  wt config set-identity gitlab.com --signing-format gpg --signing-key 3AA5C34371567BD2 --sign on`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := args[0]
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		host := cfg.GetHostConfig(domain)
+		if err := setIdentity(cmd, &host); err != nil {
+			return err
+		}
+		cfg.SetHostConfig(domain, host)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		details := identityDetails(host)
+		if details == "" {
+			details = "the global git config applies"
+		}
+		fmt.Printf("Set the commit identity for %s (%s)\n", domain, details)
+		return nil
+	},
+}
+
+// addIdentityFlags registers the flags setIdentity reads
+func addIdentityFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Author name of commits")
+	cmd.Flags().String("email", "", "Author email of commits")
+	cmd.Flags().String("signing-key", "", "GPG key ID, or path of the SSH key, commits are signed with")
+	cmd.Flags().String("signing-format", "", "Format of the signing key (gpg or ssh)")
+	cmd.Flags().String("sign", "", "Sign commits (on or off)")
+}
+
+// setIdentity updates the commit identity of host from the flags the user changed
+func setIdentity(cmd *cobra.Command, host *config.HostConfig) error {
+	var err error
+	if cmd.Flags().Changed("name") {
+		host.UserName, _ = cmd.Flags().GetString("name")
+	}
+	if cmd.Flags().Changed("email") {
+		host.UserEmail, _ = cmd.Flags().GetString("email")
+	}
+	if cmd.Flags().Changed("signing-format") {
+		format, _ := cmd.Flags().GetString("signing-format")
+		host.SigningFormat = ""
+		if format != "" {
+			if host.SigningFormat, err = config.ParseSigningFormat(format); err != nil {
+				return err
+			}
+		}
+	}
+	if cmd.Flags().Changed("signing-key") {
+		host.SigningKey, _ = cmd.Flags().GetString("signing-key")
+		// SSH keys are paths, unlike GPG key IDs and literal keys
+		if host.SigningFormat == config.SigningFormatSSH && !strings.HasPrefix(host.SigningKey, "key::") {
+			if host.SigningKey, err = configPath(cmd, "signing-key"); err != nil {
+				return err
+			}
+		}
+	}
+	if cmd.Flags().Changed("sign") {
+		sign, _ := cmd.Flags().GetString("sign")
+		switch sign {
+		case "on", "off":
+			enabled := sign == "on"
+			host.SignCommits = &enabled
+		case "":
+			host.SignCommits = nil
+		default:
+			return fmt.Errorf("invalid value %q for --sign, expected on or off", sign)
+		}
+	}
+	return nil
+}
+
+// identityDetails summarises the commit identity settings of a host
+func identityDetails(host config.HostConfig) string {
+	var details []string
+	for _, setting := range []struct{ label, value string }{
+		{"name", host.UserName},
+		{"email", host.UserEmail},
+		{"signing key", host.SigningKey},
+		{"signing format", string(host.SigningFormat)},
+	} {
+		if setting.value != "" {
+			details = append(details, setting.label+": "+setting.value)
+		}
+	}
+	if host.SignCommits != nil {
+		sign := "off"
+		if *host.SignCommits {
+			sign = "on"
+		}
+		details = append(details, "sign: "+sign)
+	}
+	return strings.Join(details, ", ")
+}
+
+var setProfileCmd = &cobra.Command{
+	Use:   "set-profile <domain> <name>",
+	Short: "Add or change a profile of a domain",
+	Long: `Add a named profile to a domain, or change an existing one. A profile is an alternative identity on
+the host: the settings it sets override the host's for repositories whose namespace, or top-level
+group, matches one of its org patterns. The first matching profile is used; 'wt setup --profile'
+picks one by name instead. Only the given flags are changed.
+
+Pair --ssh-host with an ssh_config Host alias to use a different key for the profile's repositories.
+The commit identity flags work like those of 'wt config set-identity'.
+
+Examples:
+  wt config set-profile github.com work --orgs 'acme-*,acme' --account jdoe-acme --ssh-host github-work
+  wt config set-profile github.com work --token-env ACME_GITHUB_TOKEN
+  wt config set-profile github.com work --email jane.doe@acme.com
+  wt config set-profile gitlab.com oss --orgs 'gnome/*' --account jdoe --clone-method http`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain, name := args[0], args[1]
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		profile := config.Profile{Name: name}
+		for _, p := range cfg.GetHostConfig(domain).Profiles {
+			if p.Name == name {
+				profile = p
+			}
+		}
+		if cmd.Flags().Changed("orgs") {
+			profile.Orgs, _ = cmd.Flags().GetStringSlice("orgs")
+		}
+		if cmd.Flags().Changed("account") {
+			profile.Account, _ = cmd.Flags().GetString("account")
+		}
+		if cmd.Flags().Changed("clone-method") {
+			method, _ := cmd.Flags().GetString("clone-method")
+			profile.CloneMethod = ""
+			if method != "" {
+				if profile.CloneMethod, err = config.ParseCloneMethod(method); err != nil {
+					return err
+				}
+			}
+		}
+		if cmd.Flags().Changed("ssh-host") {
+			profile.SSHHost, _ = cmd.Flags().GetString("ssh-host")
+		}
+		if cmd.Flags().Changed("identity-file") {
+			if profile.IdentityFile, err = configPath(cmd, "identity-file"); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("token-env") {
+			profile.TokenEnv, _ = cmd.Flags().GetString("token-env")
+		}
+		if cmd.Flags().Changed("token-file") {
+			if profile.TokenFile, err = configPath(cmd, "token-file"); err != nil {
+				return err
+			}
+		}
+		if err := setIdentity(cmd, &profile.HostConfig); err != nil {
+			return err
+		}
+		cfg.SetProfile(domain, profile)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("Set profile %s for %s (%s)\n", name, domain, profileDetails(profile))
+		return nil
+	},
+}
+
+var removeProfileCmd = &cobra.Command{
+	Use:   "remove-profile <domain> <name>",
+	Short: "Remove a profile of a domain",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain, name := args[0], args[1]
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !cfg.RemoveProfile(domain, name) {
+			return fmt.Errorf("no profile %q configured for %s", name, domain)
+		}
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("Removed profile %s from %s\n", name, domain)
+		return nil
+	},
+}
+
+// profileDetails summarises the settings a profile overrides
+func profileDetails(p config.Profile) string {
+	orgs := "no orgs"
+	if len(p.Orgs) > 0 {
+		orgs = "orgs: " + strings.Join(p.Orgs, ", ")
+	}
+	details := []string{orgs}
+	for _, setting := range []struct{ label, value string }{
+		{"account", p.Account},
+		{"clone", string(p.CloneMethod)},
+		{"ssh host", p.SSHHost},
+		{"identity", p.IdentityFile},
+		{"token", p.TokenEnv},
+		{"token file", p.TokenFile},
+	} {
+		if setting.value != "" {
+			details = append(details, setting.label+": "+setting.value)
+		}
+	}
+	if identity := identityDetails(p.HostConfig); identity != "" {
+		details = append(details, identity)
+	}
+	return strings.Join(details, ", ")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [dir...]",
+	Short: "Apply the commit identity and signing config to repositories already set up",
+	Long: `Write the commit identity and signing settings currently configured for each repository's host,
+or for its profile, into the repository's config, as setup does for new repositories. Repositories
+that now match a profile are switched to it.
+
+Without arguments the repository containing the current directory is updated. Otherwise every
+repository at or below each dir is updated. Settings removed from the config are removed from the
+repositories too, but only the ones wt wrote; settings made in a repository by hand are left alone.
+
+Examples:
+  wt config apply
+  wt config apply ~/src`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var roots []string
+		if len(args) == 0 {
+			root, err := git.FindGitRoot()
+			if err != nil {
+				return err
+			}
+			roots = append(roots, root)
+		}
+		for _, dir := range args {
+			found, err := setup.FindRepositories(dir)
+			if err != nil {
+				return err
+			}
+			if len(found) == 0 {
+				return fmt.Errorf("no repositories found under %s", dir)
+			}
+			roots = append(roots, found...)
+		}
+
+		var failed int
+		for _, root := range roots {
+			id, err := setup.ApplyConfig(root, cfg)
+			switch {
+			case err != nil:
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", root, err)
+			case id.IsZero():
+				fmt.Printf("%s: nothing configured\n", root)
+			default:
+				fmt.Printf("%s: %s\n", root, commitIdentityDetails(id))
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to apply the config to %d of %d repositories", failed, len(roots))
+		}
+		return nil
+	},
+}
+
+// commitIdentityDetails summarises the settings written to a repository
+func commitIdentityDetails(id git.CommitIdentity) string {
+	var details []string
+	for _, setting := range []struct{ label, value string }{
+		{"name", id.Name},
+		{"email", id.Email},
+		{"signing key", id.SigningKey},
+	} {
+		if setting.value != "" {
+			details = append(details, setting.label+": "+setting.value)
+		}
+	}
+	if id.Sign != nil {
+		sign := "off"
+		if *id.Sign {
+			sign = "on"
+		}
+		details = append(details, "sign: "+sign)
+	}
+	return strings.Join(details, ", ")
+}
+
+var setCacheCmd = &cobra.Command{
+	Use:   "set-cache <on|off> [dir]",
+	Short: "Enable or disable the shared object cache for setup",
+	Long: `Enable or disable the shared object cache that setup clones borrow objects from, optionally
+moving it to dir. The cache defaults to worktree/objects in the user cache directory.
+
+Examples:
+  wt config set-cache on
+  wt config set-cache on ~/.cache/worktree/objects
+  wt config set-cache off`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var enabled bool
+		switch args[0] {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			return fmt.Errorf("invalid value %q, expected on or off", args[0])
+		}
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var dir string
+		if len(args) > 1 {
+			dir = args[1]
+			// Keep ~/ so the config stays portable, but pin other relative paths to where the command ran
+			if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "~/") {
+				if dir, err = filepath.Abs(dir); err != nil {
+					return err
+				}
+			}
+		}
+		cfg.SetCache(enabled, dir)
+
+		if err := SaveConfigWithOverride(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		if enabled {
+			cacheDir, err := cfg.Cache.CacheDir()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Enabled the object cache in %s\n", cacheDir)
+		} else {
+			fmt.Println("Disabled the object cache")
+		}
+		return nil
+	},
+}
+
 func init() {
 	configCmd.AddCommand(setAccountCmd)
 	configCmd.AddCommand(listCmd)
 	configCmd.AddCommand(setCloneMethodCmd)
+	configCmd.AddCommand(setSelectorCmd)
+	configCmd.AddCommand(setLayoutCmd)
+	configCmd.AddCommand(setCacheCmd)
+	configCmd.AddCommand(setTokenCmd)
+	configCmd.AddCommand(setSSHCmd)
+	configCmd.AddCommand(setProfileCmd)
+	configCmd.AddCommand(removeProfileCmd)
+	configCmd.AddCommand(setIdentityCmd)
+	configCmd.AddCommand(applyCmd)
+
+	setTokenCmd.Flags().String("env", "", "Environment variable holding the token")
+	setTokenCmd.Flags().String("file", "", "File holding the token")
+
+	setSSHCmd.Flags().String("user", "", "User in generated SSH URLs (default git)")
+	setSSHCmd.Flags().String("port", "", "SSH port used in generated SSH URLs")
+	setSSHCmd.Flags().String("identity-file", "", "Private key to authenticate with")
+	setSSHCmd.Flags().String("passphrase-env", "", "Environment variable holding the key's passphrase")
+	setSSHCmd.Flags().String("passphrase-file", "", "File holding the key's passphrase")
+	setSSHCmd.Flags().String("host-key-fingerprint", "", "Pinned SHA256 fingerprint of the host's SSH key")
+
+	setProfileCmd.Flags().StringSlice("orgs", nil, "Org patterns the profile is used for, e.g. acme-*")
+	setProfileCmd.Flags().String("account", "", "Account used for forks")
+	setProfileCmd.Flags().String("clone-method", "", "Clone method (http or ssh)")
+	setProfileCmd.Flags().String("ssh-host", "", "Host, usually an ssh_config alias, used in place of the domain in SSH URLs")
+	setProfileCmd.Flags().String("identity-file", "", "Private key to authenticate with")
+	setProfileCmd.Flags().String("token-env", "", "Environment variable holding the HTTPS access token")
+	setProfileCmd.Flags().String("token-file", "", "File holding the HTTPS access token")
+	addIdentityFlags(setProfileCmd)
+
+	addIdentityFlags(setIdentityCmd)
 }
@@ -0,0 +1,88 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/spf13/cobra"
+)
+
+var credentialCmd = &cobra.Command{
+	Use:    "credential <get|store|erase>",
+	Short:  "Git credential helper serving the configured access tokens",
+	Hidden: true,
+	Long: `Implements the git credential helper protocol so that git commands run in repositories created
+by setup authenticate with the access token configured for the host, or for the profile the
+repository was set up with. Setup configures it in
+repositories whose host has a token; it answers nothing for other hosts so git asks the next helper.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Tokens come from the config, so there is nothing to store or erase
+		if args[0] != "get" {
+			return nil
+		}
+
+		var host, repoPath string
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() && scanner.Text() != "" {
+			switch key, value, _ := strings.Cut(scanner.Text(), "="); key {
+			case "host":
+				host = value
+			case "path":
+				repoPath = value
+			}
+		}
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		// The host may include a port, which isn't part of the configured domain
+		domain, _, _ := strings.Cut(host, ":")
+		// git runs helpers inside the repository, which records the profile it was set up with. Git
+		// only sends the path when credential.useHttpPath is set.
+		profile, _ := git.RunGitCommandOutput("config", setup.ProfileKey)
+		var namespace string
+		if repoPath != "" {
+			namespace = path.Dir(repoPath)
+		}
+		if cfg, _, err = cfg.ApplyProfile(domain, namespace, profile); err != nil {
+			return err
+		}
+		username, token, err := cfg.GetToken(domain)
+		if err != nil || token == "" {
+			return err
+		}
+		fmt.Printf("username=%s\npassword=%s\n", username, token)
+		return nil
+	},
+}
+
+// credentialHelper returns the git credential helper that runs this binary's credential command
+// with the config file in use, so tokens come from the same settings as setup's
+func credentialHelper() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return "!" + shellQuote(exe) + configArgs() + " credential"
+}
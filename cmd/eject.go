@@ -0,0 +1,128 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/liamawhite/worktree/pkg/worktree"
+	"github.com/spf13/cobra"
+)
+
+var ejectCmd = &cobra.Command{
+	Use:   "eject [worktree-name]",
+	Short: "Convert the worktree layout back into a plain clone",
+	Long: `Convert a repository created by setup or adopt back into a conventional clone.
+
+The .bare directory becomes the .git directory of the chosen worktree, which defaults to the
+current worktree or is selected interactively. The other worktrees are removed after checking
+they have no uncommitted changes, or reattached to the new clone with --keep-worktrees.
+Branches are never deleted. The .hooks directory and the gitdir file are removed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wm, err := newWorktreeManager()
+		if err != nil {
+			return fmt.Errorf("must be in a git repository to eject: %w", err)
+		}
+
+		worktrees, err := git.ListWorktrees(wm.GitRoot)
+		if err != nil {
+			return err
+		}
+		var dirs []string
+		for _, wt := range worktrees {
+			if !wt.Bare {
+				dirs = append(dirs, wt.Path)
+			}
+		}
+
+		target, err := ejectTarget(wm, dirs, args)
+		if err != nil || target == "" {
+			return err
+		}
+
+		keep, _ := cmd.Flags().GetBool("keep-worktrees")
+		if skip, _ := cmd.Flags().GetBool("yes"); !skip && !keep && len(dirs) > 1 {
+			var lines []string
+			for _, dir := range dirs {
+				if dir != target {
+					lines = append(lines, filepath.Base(dir))
+				}
+			}
+
+			sel, err := newSelector(wm)
+			if err != nil {
+				return err
+			}
+			title := fmt.Sprintf("%s will become a plain clone and these worktrees will be removed (branches are kept):", filepath.Base(target))
+			confirmed, err := sel.Confirm(title, lines)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("No action taken")
+				return nil
+			}
+		}
+
+		if err := setup.Eject(wm.GitRoot, target, setup.EjectOptions{KeepWorktrees: keep}); err != nil {
+			return err
+		}
+
+		fmt.Printf("Ejected repository, the clone is now at %s\n", target)
+		fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", target)
+		return nil
+	},
+}
+
+// ejectTarget resolves the worktree that becomes the plain clone: the named one, the current one,
+// or an interactively selected one. Returns empty string if the selection was cancelled.
+func ejectTarget(wm *worktree.WorktreeManager, dirs []string, args []string) (string, error) {
+	if len(args) > 0 {
+		for _, dir := range dirs {
+			if rel, err := filepath.Rel(wm.GitRoot, dir); filepath.Base(dir) == args[0] || (err == nil && rel == args[0]) {
+				return dir, nil
+			}
+		}
+		return "", fmt.Errorf("worktree '%s' not found", args[0])
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+			cwd = resolved
+		}
+		for _, dir := range dirs {
+			if cwd == dir || strings.HasPrefix(cwd, dir+string(filepath.Separator)) {
+				return dir, nil
+			}
+		}
+	}
+
+	sel, err := newSelector(wm)
+	if err != nil {
+		return "", err
+	}
+	return sel.Select("Select the worktree to keep as the plain clone:", worktreeItems(wm, dirs))
+}
+
+func init() {
+	ejectCmd.Flags().Bool("keep-worktrees", false, "Reattach the other worktrees to the new clone instead of removing them")
+	ejectCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation before removing the other worktrees")
+}
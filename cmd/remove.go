@@ -18,25 +18,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/liamawhite/worktree/pkg/selector"
-	"github.com/liamawhite/worktree/pkg/worktree"
 	"github.com/spf13/cobra"
 )
 
 var rmCmd = &cobra.Command{
 	Use:     "rm [worktree-name]",
 	Aliases: []string{"d", "delete", "del", "remove"},
-	Short:   "Remove a worktree",
-	Long:    `Remove a worktree by name, or interactively select one if no name is provided (excluding main/master and review).`,
-	Args:    cobra.MaximumNArgs(1),
+	Short:   "Remove one or more worktrees",
+	Long: `Remove a worktree by name, or interactively select one or more if no name is provided (excluding main/master and review).
+
+In the interactive selector press space to toggle a worktree and a to select all of them.
+A confirmation listing the branches that will be deleted is shown before anything is removed.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		wm, err := worktree.NewWorktreeManager()
+		wm, err := newWorktreeManager()
 		if err != nil {
 			return fmt.Errorf("must be in a git repository to remove worktrees: %w", err)
 		}
 
-		var selectedWorktree string
+		var selectedWorktrees []string
+		var sel selector.Selector
 
 		if len(args) > 0 {
 			worktreeName := args[0]
@@ -47,12 +51,12 @@ var rmCmd = &cobra.Command{
 
 			for _, wt := range allWorktrees {
 				if filepath.Base(wt) == worktreeName {
-					selectedWorktree = wt
+					selectedWorktrees = append(selectedWorktrees, wt)
 					break
 				}
 			}
 
-			if selectedWorktree == "" {
+			if len(selectedWorktrees) == 0 {
 				return fmt.Errorf("worktree '%s' not found", worktreeName)
 			}
 		} else {
@@ -66,29 +70,74 @@ var rmCmd = &cobra.Command{
 				return nil
 			}
 
-			selectedWorktree, err = selector.Select("Select a worktree to remove:", filteredWorktrees)
+			sel, err = newSelector(wm)
 			if err != nil {
 				return err
 			}
 
-			if selectedWorktree == "" {
+			selectedWorktrees, err = sel.SelectMany("Select worktrees to remove:", worktreeItems(wm, filteredWorktrees))
+			if err != nil {
+				return err
+			}
+
+			if len(selectedWorktrees) == 0 {
 				fmt.Println("No worktree selected, no action taken")
 				return nil
 			}
 		}
 
-		worktreeName := filepath.Base(selectedWorktree)
-		fmt.Printf("Removing worktree: %s\n", worktreeName)
+		for _, wt := range selectedWorktrees {
+			if err := wm.CheckRemovable(wt); err != nil {
+				return err
+			}
+		}
 
-		needsChdir, err := wm.RemoveWorktree(selectedWorktree)
-		if err != nil {
-			return err
+		if skip, _ := cmd.Flags().GetBool("yes"); !skip && len(args) == 0 {
+			var lines []string
+			for _, info := range wm.GetWorktreeInfo(selectedWorktrees) {
+				line := fmt.Sprintf("%s (branch %s)", info.Name, info.Branch)
+				if info.Dirty {
+					line += " - has uncommitted changes"
+				}
+				lines = append(lines, line)
+			}
+
+			confirmed, err := sel.Confirm("The following worktrees and their branches will be deleted:", lines)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("No action taken")
+				return nil
+			}
+		}
+
+		needsChdir := false
+		var failed []string
+		for _, wt := range selectedWorktrees {
+			worktreeName := filepath.Base(wt)
+			fmt.Printf("Removing worktree: %s\n", worktreeName)
+
+			removedCurrent, err := wm.RemoveWorktree(os.Stdout, wt)
+			needsChdir = needsChdir || removedCurrent
+			if err != nil {
+				fmt.Printf("Warning: failed to remove worktree %s: %v\n", worktreeName, err)
+				failed = append(failed, worktreeName)
+			}
 		}
 
 		if needsChdir {
 			fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", wm.GitRoot)
 		}
 
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to remove worktrees: %s", strings.Join(failed, ", "))
+		}
+
 		return nil
 	},
 }
+
+func init() {
+	rmCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation when removing interactively selected worktrees")
+}
@@ -17,9 +17,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/progress"
 	"github.com/liamawhite/worktree/pkg/version"
+	"github.com/liamawhite/worktree/pkg/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -51,6 +54,19 @@ func getConfigPath() string {
 	return getDefaultConfigPath()
 }
 
+// configArgs returns the --config flag that makes commands this binary runs later, such as the selector
+// preview, use the same config file. The path is made absolute as they may run in another directory.
+func configArgs() string {
+	path := getConfigPath()
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return " --config " + shellQuote(path)
+}
+
 var RootCmd = &cobra.Command{
 	Use:   "worktree",
 	Short: "Git worktree management tool",
@@ -76,17 +92,26 @@ func init() {
 	// Add global config flag with explicit default
 	defaultPath := getDefaultConfigPath()
 	RootCmd.PersistentFlags().StringP("config", "c", defaultPath, "config file path")
+	RootCmd.PersistentFlags().String("progress", string(progress.ModeAuto), "How to show clone and fetch progress: auto, tty, plain, quiet or json")
 
 	// Add version flag
 	RootCmd.Flags().BoolP("version", "v", false, "show version information")
 
 	RootCmd.AddCommand(setupCmd)
+	RootCmd.AddCommand(adoptCmd)
+	RootCmd.AddCommand(ejectCmd)
+	RootCmd.AddCommand(workspaceCmd)
+	RootCmd.AddCommand(cacheCmd)
+	RootCmd.AddCommand(sshCmd)
 	RootCmd.AddCommand(addCmd)
 	RootCmd.AddCommand(rmCmd)
 	RootCmd.AddCommand(clearCmd)
 	RootCmd.AddCommand(switchCmd)
+	RootCmd.AddCommand(uiCmd)
 	RootCmd.AddCommand(configCmd)
 	RootCmd.AddCommand(versionCmd)
+	RootCmd.AddCommand(previewCmd)
+	RootCmd.AddCommand(credentialCmd)
 }
 
 // LoadConfigWithOverride loads config using the resolved config path
@@ -94,6 +119,25 @@ func LoadConfigWithOverride() (*config.Config, error) {
 	return config.LoadConfigFromPath(getConfigPath())
 }
 
+// newReporter returns the progress reporter selected with --progress, writing to stdout
+func newReporter(cmd *cobra.Command) (progress.Reporter, error) {
+	value, _ := cmd.Flags().GetString("progress")
+	mode, err := progress.ParseMode(value)
+	if err != nil {
+		return nil, err
+	}
+	return progress.New(mode, os.Stdout)
+}
+
+// newWorktreeManager finds the current repository, placing worktrees of standard clones as configured in settings.yaml
+func newWorktreeManager() (*worktree.WorktreeManager, error) {
+	cfg, err := LoadConfigWithOverride()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return worktree.NewWorktreeManagerWithConfig(cfg)
+}
+
 // SaveConfigWithOverride saves config using the resolved config path
 func SaveConfigWithOverride(cfg *config.Config) error {
 	return cfg.SaveToPath(getConfigPath())
@@ -0,0 +1,76 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/selector"
+	"github.com/liamawhite/worktree/pkg/worktree"
+	"github.com/spf13/cobra"
+)
+
+var previewCmd = &cobra.Command{
+	Use:    "preview <worktree-path>",
+	Short:  "Print the selector preview for a worktree",
+	Long:   `Print the status, recent commits and diffstat of a worktree. Used as the preview command of external finders such as fzf.`,
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wm, err := newWorktreeManager()
+		if err != nil {
+			return err
+		}
+		fmt.Print(wm.Preview(args[0]))
+		return nil
+	},
+}
+
+// newSelector returns the selector backend configured in settings.yaml
+func newSelector(wm *worktree.WorktreeManager) (selector.Selector, error) {
+	cfg, err := LoadConfigWithOverride()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	preview := selector.Preview{Func: wm.Preview, Status: wm.IsDirty}
+	if exe, err := os.Executable(); err == nil {
+		preview.Command = shellQuote(exe) + configArgs() + " preview {1}"
+	}
+
+	return selector.New(cfg.Selector, preview)
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// worktreeItems converts worktree directories into selector items with branch badges. The status
+// badges are loaded by the selector, so it shows without waiting for git status in every worktree.
+func worktreeItems(wm *worktree.WorktreeManager, dirs []string) []selector.Item {
+	infos := wm.GetWorktreeBranches(dirs)
+	items := make([]selector.Item, len(infos))
+	for i, info := range infos {
+		items[i] = selector.Item{
+			Path:   info.Path,
+			Name:   info.Name,
+			Branch: info.Branch,
+		}
+	}
+	return items
+}
@@ -18,35 +18,213 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/liamawhite/worktree/pkg/cache"
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/progress"
+	"github.com/liamawhite/worktree/pkg/selector"
 	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/liamawhite/worktree/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
 var setupCmd = &cobra.Command{
-	Use:   "setup [domain/]org/repo",
+	Use:   "setup <[domain/]namespace/repo | clone-url | path> | -f <workspace.yaml>",
 	Short: "Setup a new worktree repository",
 	Long: `Setup a new repository with worktrees. Supports both GitHub.com and GitHub Enterprise.
-Clones the repository and configures upstream/origin remotes.`,
-	Args: cobra.ExactArgs(1),
+Clones the repository and configures upstream/origin remotes.
+
+The repository can be given as [domain/]namespace/repo shorthand or as a clone URL, for example
+https://github.com/org/repo.git, git@github.com:org/repo.git or ssh://git@host:7999/org/repo.git.
+On GitLab the namespace can be a nested group path such as gitlab.com/group/subgroup/repo.
+
+Local repositories can be set up from a path (absolute, or starting with ./, ../ or ~/) or a file:// URL.
+They are cloned directly with origin pointing at the given location.
+
+Setup can be run again on an existing directory to finish an interrupted setup: completed steps are
+skipped, remotes with the wrong URL are corrected and missing worktrees are created. If a first-time
+setup fails you are asked whether to remove the directory it created.
+
+The repository is created in the current directory, or in --dir, in a folder named after the repository.
+Use --name to choose a different folder name, for example to keep two forks of the same repository side by side.
+
+Huge repositories can be cloned partially with --filter=blob:none, shallowly with --depth and with only the
+base branch using --single-branch. The choice is recorded in the repository, so later fetches keep the same
+depth and 'wt add' fetches a base branch that hasn't been cloned yet.
+
+When the host has profiles, the first one whose orgs match the repository's namespace supplies the
+account, clone method and keys, e.g. a work account for acme-* orgs. Use --profile to pick one by name.
+The commit identity and signing settings of the host or profile (see 'wt config set-identity') are
+written into the repository's config.
+
+With --cache, or when enabled with 'wt config set-cache on', the upstream is kept in a shared object cache
+and the clone borrows its objects through git alternates, so forks and repeated setups of the same
+repository store them once. Use 'wt cache update' and 'wt cache gc' to maintain the cache.
+
+With -f, every repository listed in a workspace manifest is set up, several at a time, followed by a
+summary of which succeeded. --base and --dir set the defaults for repositories that don't set their own.
+
+  dir: ~/src
+  jobs: 4
+  repos:
+    - repo: github.com/org/service-a
+      branch: develop
+    - domain: gitlab.company.com
+      org: platform/team
+      repo_name: service-b
+      name: service-b-fork
+      remotes:
+        - name: colleague
+          url: git@gitlab.company.com:colleague/service-b.git
+      worktrees: [feature/login]`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if file, _ := cmd.Flags().GetString("file"); file != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		repo := args[0]
 		branch, _ := cmd.Flags().GetString("base")
+		if file, _ := cmd.Flags().GetString("file"); file != "" {
+			return setupWorkspace(cmd, file, branch)
+		}
+
+		repo := args[0]
 
 		config, err := setup.ParseRepoString(repo, branch)
 		if err != nil {
 			return err
 		}
 
-		if err := setup.SetupRepository(config, getConfigPath()); err != nil {
+		dir, _ := cmd.Flags().GetString("dir")
+		name, _ := cmd.Flags().GetString("name")
+
+		objects, err := objectCache(cmd)
+		if err != nil {
+			return err
+		}
+
+		reporter, err := newReporter(cmd)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = reporter.Close() }()
+		out := progress.NewWriter(reporter, "")
+
+		profile, _ := cmd.Flags().GetString("profile")
+		opts := setup.Options{Dir: dir, Name: name, Clone: cloneOptions(cmd), Cache: objects, CredentialHelper: credentialHelper(), Profile: profile, Out: out,
+			ConfirmCleanup: func(dir string) bool {
+				// The prompt needs the terminal to itself
+				out.Flush()
+				_ = reporter.Close()
+				return confirmCleanup(dir)
+			},
+			ConfirmHostKey: func(key git.HostKey) bool {
+				out.Flush()
+				_ = reporter.Close()
+				return confirmHostKey(key)
+			},
+		}
+		root, err := setup.SetupRepository(config, getConfigPath(), opts)
+		out.Flush()
+		_ = reporter.Close()
+		if err != nil {
 			return err
 		}
 
 		// Change to the newly created repository directory
-		fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", config.RepoName)
+		fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", root)
 		return nil
 	},
 }
 
+// setupWorkspace sets up every repository in the manifest and prints a summary
+func setupWorkspace(cmd *cobra.Command, file, branch string) error {
+	for _, flag := range []string{"name", "filter", "depth", "single-branch", "profile"} {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s can't be used with a workspace manifest, set it on the repository instead", flag)
+		}
+	}
+
+	m, err := workspace.Load(file)
+	if err != nil {
+		return err
+	}
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		m.Dir = dir
+	}
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	objects, err := objectCache(cmd)
+	if err != nil {
+		return err
+	}
+
+	reporter, err := newReporter(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = reporter.Close() }()
+
+	results, err := workspace.Setup(m, getConfigPath(), workspace.Options{Jobs: jobs, Branch: branch, Cache: objects, CredentialHelper: credentialHelper(), Progress: reporter})
+	if err != nil {
+		return err
+	}
+
+	// The summary goes through the reporter so it is part of a JSON stream too
+	out := progress.NewWriter(reporter, "")
+	if err := workspace.WriteSummary(out, results); err != nil {
+		return err
+	}
+	out.Flush()
+	_ = reporter.Close()
+	if failed := workspace.Failed(results); failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed to set up, run the same command again to resume them", failed, len(results))
+	}
+	return nil
+}
+
+// cloneOptions returns the partial and shallow clone flags
+func cloneOptions(cmd *cobra.Command) git.CloneOptions {
+	filter, _ := cmd.Flags().GetString("filter")
+	depth, _ := cmd.Flags().GetInt("depth")
+	singleBranch, _ := cmd.Flags().GetBool("single-branch")
+	return git.CloneOptions{Filter: filter, Depth: depth, SingleBranch: singleBranch}
+}
+
+// objectCache returns the shared object cache when --cache or the config enables it, or nil
+func objectCache(cmd *cobra.Command) (*cache.Cache, error) {
+	cfg, err := LoadConfigWithOverride()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	enabled := cfg.Cache.Enabled
+	if cmd.Flags().Changed("cache") {
+		enabled, _ = cmd.Flags().GetBool("cache")
+	}
+	if !enabled {
+		return nil, nil
+	}
+	dir, err := cfg.Cache.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.New(dir), nil
+}
+
+// confirmCleanup asks whether to remove the directory left behind by a failed setup
+func confirmCleanup(dir string) bool {
+	ok, err := selector.NewPrompt().Confirm("Setup failed. Remove the directory it created?", []string{dir})
+	return err == nil && ok
+}
+
 func init() {
 	setupCmd.Flags().StringP("base", "b", "main", "Base branch to use for the repository")
+	setupCmd.Flags().StringP("dir", "d", "", "Directory to create the repository in (defaults to the current directory)")
+	setupCmd.Flags().StringP("name", "n", "", "Local folder name for the repository (defaults to the repository name)")
+	setupCmd.Flags().String("filter", "", "Partial clone filter, e.g. blob:none to fetch file contents on demand")
+	setupCmd.Flags().Int("depth", 0, "Clone only the most recent commits of history")
+	setupCmd.Flags().Bool("single-branch", false, "Clone only the base branch; other bases are fetched when a worktree needs them")
+	setupCmd.Flags().String("profile", "", "Host profile to use instead of the one matching the repository's org")
+	setupCmd.Flags().Bool("cache", false, "Borrow objects from the shared object cache (defaults to the cache.enabled config)")
+	setupCmd.Flags().StringP("file", "f", "", "Workspace manifest listing repositories to set up")
+	setupCmd.Flags().IntP("jobs", "j", 0, fmt.Sprintf("Number of repositories to set up at the same time with -f (defaults to the manifest's jobs, or %d)", workspace.DefaultJobs))
 }
@@ -0,0 +1,111 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/selector"
+	"github.com/spf13/cobra"
+)
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh",
+	Short: "Manage SSH host keys",
+}
+
+var sshTrustCmd = &cobra.Command{
+	Use:   "trust <host>",
+	Short: "Add the SSH key of a host to known_hosts",
+	Long: `Fetch the SSH key of a host, show its fingerprint and add it to known_hosts once confirmed.
+
+The host can be an ssh_config Host alias and may include a port, e.g. bitbucket.company.com:7999;
+otherwise the port configured with 'wt config set-ssh' is used. When the host has a pinned
+fingerprint, set with 'wt config set-ssh --host-key-fingerprint' or given with --fingerprint, the key
+is added without asking if it matches and rejected if it doesn't. Compare the fingerprint with the
+one your Git host publishes before trusting it.
+
+A key that differs from the one already in known_hosts is never replaced: remove the old key with
+'ssh-keygen -R <host>' once you know why it changed.
+
+Examples:
+  wt ssh trust github.com
+  wt ssh trust bitbucket.company.com:7999
+  wt ssh trust github.com --fingerprint SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host := args[0]
+
+		cfg, err := LoadConfigWithOverride()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		domain, _, err := net.SplitHostPort(host)
+		if err != nil {
+			domain = host
+		}
+		hostConfig := cfg.GetHostConfig(domain)
+		if domain == host && hostConfig.Port != "" {
+			host = net.JoinHostPort(host, hostConfig.Port)
+		}
+
+		opts := git.SSHOptions{HostKeyFingerprint: hostConfig.HostKeyFingerprint, ConfirmHostKey: confirmHostKey}
+		if cmd.Flags().Changed("fingerprint") {
+			opts.HostKeyFingerprint, _ = cmd.Flags().GetString("fingerprint")
+		}
+		if yes, _ := cmd.Flags().GetBool("yes"); yes {
+			opts.ConfirmHostKey = func(git.HostKey) bool { return true }
+		}
+
+		key, err := git.FetchHostKey(host)
+		if err != nil {
+			return err
+		}
+		if err := git.CheckHostKey(key); err == nil {
+			fmt.Printf("%s is already trusted (%s key %s)\n", key.Host, key.Type, key.Fingerprint)
+			return nil
+		}
+
+		err = opts.VerifyHostKey(key)
+		var unknown *git.UnknownHostError
+		if errors.As(err, &unknown) {
+			return fmt.Errorf("did not trust %s", key.Host)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added the %s key of %s (%s) to known_hosts\n", key.Type, key.Host, key.Fingerprint)
+		return nil
+	},
+}
+
+// confirmHostKey asks whether to trust the key of a host that isn't in known_hosts
+func confirmHostKey(key git.HostKey) bool {
+	ok, err := selector.NewPrompt().Confirm(
+		fmt.Sprintf("The SSH host %s is not known yet. Check its fingerprint against the one the host publishes before adding it to known_hosts:", key.Host),
+		[]string{key.Type + " " + key.Fingerprint},
+	)
+	return err == nil && ok
+}
+
+func init() {
+	sshCmd.AddCommand(sshTrustCmd)
+
+	sshTrustCmd.Flags().String("fingerprint", "", "SHA256 fingerprint the key must have, overriding the configured one")
+	sshTrustCmd.Flags().BoolP("yes", "y", false, "Trust the key without asking")
+}
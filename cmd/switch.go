@@ -19,8 +19,6 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/liamawhite/worktree/pkg/selector"
-	"github.com/liamawhite/worktree/pkg/worktree"
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +28,7 @@ var switchCmd = &cobra.Command{
 	Short:   "Switch to a different worktree",
 	Long:    `Switch to a different worktree. If no worktree is specified, interactively select one.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		wm, err := worktree.NewWorktreeManager()
+		wm, err := newWorktreeManager()
 		if err != nil {
 			return fmt.Errorf("must be in a git repository to switch worktrees: %w", err)
 		}
@@ -59,7 +57,12 @@ var switchCmd = &cobra.Command{
 				return fmt.Errorf("worktree '%s' not found", targetWorktree)
 			}
 		} else {
-			selectedWorktree, err = selector.Select("Select a worktree to switch to:", worktreeDirs)
+			sel, err := newSelector(wm)
+			if err != nil {
+				return err
+			}
+
+			selectedWorktree, err = sel.Select("Select a worktree to switch to:", worktreeItems(wm, worktreeDirs))
 			if err != nil {
 				return err
 			}
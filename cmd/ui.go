@@ -0,0 +1,50 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/liamawhite/worktree/pkg/dashboard"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Open the interactive worktree dashboard",
+	Long: `Open a full-screen dashboard listing the repository's worktrees with live status.
+
+From the dashboard you can create, remove, lock and open worktrees in your editor,
+and fetch or sync them. The git commands that are run are shown in the log panel.
+Pressing enter on a worktree exits the dashboard and switches to it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wm, err := newWorktreeManager()
+		if err != nil {
+			return fmt.Errorf("must be in a git repository to open the dashboard: %w", err)
+		}
+
+		dir, err := dashboard.Run(wm)
+		if err != nil {
+			return err
+		}
+
+		if dir != "" {
+			fmt.Fprintf(os.Stderr, "WT_CHDIR:%s\n", dir)
+		}
+		return nil
+	},
+}
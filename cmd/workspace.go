@@ -0,0 +1,93 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/liamawhite/worktree/pkg/workspace"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Export and import sets of repositories",
+	Long:  `Record the repositories and worktrees under a directory in a workspace manifest, and recreate them elsewhere.`,
+}
+
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export [root]",
+	Short: "Write a manifest of the repositories under a directory",
+	Long: `Write a workspace manifest describing every repository under root (default: the current directory),
+whether set up by wt or a standard clone. For each repository the remotes, base branch and worktrees
+with their branches and upstreams are recorded. Import recreates every repository in the layout used by
+setup, with a worktree per branch. The manifest is written to stdout unless --output is given.
+
+Examples:
+  wt workspace export ~/src -o workspace.yaml
+  wt workspace export > workspace.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+
+		m, err := workspace.Export(root)
+		if err != nil {
+			return err
+		}
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			return yaml.NewEncoder(os.Stdout).Encode(m)
+		}
+		if err := m.Save(output); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d repositories to %s\n", len(m.Repos), output)
+		return nil
+	},
+}
+
+var workspaceImportCmd = &cobra.Command{
+	Use:   "import <manifest>",
+	Short: "Recreate the repositories and worktrees in a manifest",
+	Long: `Set up every repository in a workspace manifest, including its remotes and worktrees. Repositories
+are created under --dir (default: the current directory) at the same relative locations they were
+exported from. This is equivalent to 'wt setup -f <manifest>', and can be re-run to resume.
+
+Examples:
+  wt workspace import workspace.yaml --dir ~/src`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		branch, _ := cmd.Flags().GetString("base")
+		return setupWorkspace(cmd, args[0], branch)
+	},
+}
+
+func init() {
+	workspaceExportCmd.Flags().StringP("output", "o", "", "File to write the manifest to (defaults to stdout)")
+
+	workspaceImportCmd.Flags().StringP("dir", "d", "", "Directory to create the repositories in (defaults to the current directory)")
+	workspaceImportCmd.Flags().IntP("jobs", "j", 0, fmt.Sprintf("Number of repositories to set up at the same time (defaults to the manifest's jobs, or %d)", workspace.DefaultJobs))
+	workspaceImportCmd.Flags().StringP("base", "b", "main", "Base branch for repositories that don't set one")
+	workspaceImportCmd.Flags().Bool("cache", false, "Borrow objects from the shared object cache (defaults to the cache.enabled config)")
+
+	workspaceCmd.AddCommand(workspaceExportCmd)
+	workspaceCmd.AddCommand(workspaceImportCmd)
+}
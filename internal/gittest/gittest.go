@@ -0,0 +1,79 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gittest provides fixtures for tests that run git
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs git in dir with a fixed author and committer, failing the test when it fails. Returns the
+// trimmed output.
+func Run(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, output)
+	return strings.TrimSpace(string(output))
+}
+
+// InitRepo creates a repository on branch main with a single commit adding README.md
+func InitRepo(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	Run(t, dir, "init", "--initial-branch=main")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0644))
+	Run(t, dir, "add", ".")
+	Run(t, dir, "commit", "-m", "initial commit")
+	return dir
+}
+
+// NewBareLayout clones a new repository into the .bare layout with a main worktree and a worktree
+// per branch. Returns the repository root with symlinks resolved.
+func NewBareLayout(t testing.TB, branches ...string) string {
+	t.Helper()
+	src := InitRepo(t)
+	root := t.TempDir()
+	Run(t, root, "clone", "--bare", src, ".bare")
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: ./.bare"), 0644))
+	Run(t, root, "worktree", "add", "main")
+	for _, branch := range branches {
+		Run(t, root, "worktree", "add", "-b", branch, branch)
+	}
+	resolved, err := filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	return resolved
+}
+
+// InitBareLayout creates an empty repository in the .bare layout at root whose origin is url, and
+// returns the path of .bare
+func InitBareLayout(t testing.TB, root, url string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(root, 0755))
+	Run(t, root, "init", "--bare", "--quiet", ".bare")
+	Run(t, root, "--git-dir=.bare", "remote", "add", "origin", url)
+	return filepath.Join(root, ".bare")
+}
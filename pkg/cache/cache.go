@@ -0,0 +1,295 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache keeps one bare repository per upstream that clones of the upstream and its forks
+// borrow objects from through git alternates, so the objects are stored on disk once.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+)
+
+// dependentKey is the git config key listing the repositories that borrow objects from a cached repository
+const dependentKey = "wt.dependent"
+
+// dependentRefs is the namespace the refs of dependents are kept under so gc never prunes their objects
+const dependentRefs = "refs/wt/dependents/"
+
+// Cache is a directory of cached bare repositories laid out as <host>/<namespace>/<repo>.git.
+// It is safe for concurrent use, including by several wt processes.
+type Cache struct {
+	Dir string
+}
+
+// New returns the cache kept in dir
+func New(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// lock serialises work on the cached repository at path through the lock file next to it, so
+// concurrent setups in different processes don't clone into the same path
+func (c *Cache) lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// Path returns where the repository is cached
+func (c *Cache) Path(host, namespace, repo string) string {
+	return filepath.Join(c.Dir, host, filepath.FromSlash(namespace), repo+".git")
+}
+
+// Ensure caches the repository at url in path, or brings an existing cached copy up to date
+func (c *Cache) Ensure(out io.Writer, path, url string) error {
+	unlock, err := c.lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+		return fetch(out, path)
+	}
+
+	_, _ = fmt.Fprintf(out, "Caching %s in %s\n", url, path)
+	if err := git.RunGitCommandInDirTo(out, filepath.Dir(path), "clone", "--bare", "--progress", "--", url, path); err != nil {
+		_ = os.RemoveAll(path)
+		return fmt.Errorf("failed to cache %s: %w", url, err)
+	}
+	// A bare clone has no fetch refspec. Only branches and tags are mirrored so that pruning on
+	// fetch never touches the refs kept for dependents.
+	for _, args := range [][]string{
+		{"config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"},
+		{"config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*"},
+	} {
+		if err := git.RunGitCommandInDirTo(out, path, append([]string{"--git-dir=" + path}, args...)...); err != nil {
+			return err
+		}
+	}
+	return disableAutoGC(path)
+}
+
+// Register records that the repository at gitDir borrows objects from the cached repository at path
+func (c *Cache) Register(path, gitDir string) error {
+	unlock, err := c.lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	gitDir, err = filepath.Abs(gitDir)
+	if err != nil {
+		return err
+	}
+	for _, dependent := range dependents(path) {
+		if dependent == gitDir {
+			return nil
+		}
+	}
+	_, err = git.RunGitCommandOutput("--git-dir="+path, "config", "--add", dependentKey, gitDir)
+	return err
+}
+
+// Repos returns the paths of the cached repositories
+func (c *Cache) Repos() ([]string, error) {
+	var repos []string
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.Dir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() && strings.HasSuffix(d.Name(), ".git") {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// Update fetches the latest branches and tags into every cached repository
+func (c *Cache) Update(out io.Writer) error {
+	repos, err := c.Repos()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, repo := range repos {
+		_, _ = fmt.Fprintf(out, "Updating %s\n", repo)
+		if err := c.update(out, repo); err != nil {
+			_, _ = fmt.Fprintf(out, "Warning: failed to update %s: %v\n", repo, err)
+			failed = append(failed, repo)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// GC drops dependents that no longer exist, keeps the objects of the remaining ones reachable and
+// garbage collects every cached repository. Cached repositories without dependents are removed.
+func (c *Cache) GC(out io.Writer) error {
+	repos, err := c.Repos()
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := c.gcRepo(out, repo); err != nil {
+			return fmt.Errorf("failed to gc %s: %w", repo, err)
+		}
+	}
+	return nil
+}
+
+func (c *Cache) update(out io.Writer, repo string) error {
+	unlock, err := c.lock(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fetch(out, repo)
+}
+
+func (c *Cache) gcRepo(out io.Writer, repo string) error {
+	unlock, err := c.lock(repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	live := 0
+	for _, dependent := range dependents(repo) {
+		ref := dependentRefs + dependentID(dependent)
+		if !borrowsFrom(dependent, repo) {
+			_, _ = fmt.Fprintf(out, "Forgetting %s, it no longer uses %s\n", dependent, repo)
+			if err := forget(repo, dependent, ref); err != nil {
+				return err
+			}
+			continue
+		}
+		live++
+		// Fetching the dependent's refs copies its own objects into the cache and makes everything
+		// it borrows reachable, so gc can't prune objects the dependent relies on
+		if err := git.RunGitCommandInDirTo(out, repo, "--git-dir="+repo, "fetch", "--progress", "--no-tags", "--prune", dependent, "+refs/*:"+ref+"/*"); err != nil {
+			return fmt.Errorf("failed to protect objects of %s: %w", dependent, err)
+		}
+	}
+
+	if live == 0 {
+		_, _ = fmt.Fprintf(out, "Removing %s, no repositories use it\n", repo)
+		// The lock file stays, another process may be waiting on it
+		return os.RemoveAll(repo)
+	}
+
+	_, _ = fmt.Fprintf(out, "Collecting garbage in %s\n", repo)
+	return git.RunGitCommandInDirTo(out, repo, "--git-dir="+repo, "gc", "--quiet")
+}
+
+// fetch brings repo up to date. Automatic gc is turned off first, for repositories cached by
+// versions that left it on.
+func fetch(out io.Writer, repo string) error {
+	if err := disableAutoGC(repo); err != nil {
+		return err
+	}
+	return git.RunGitCommandInDirTo(out, repo, "--git-dir="+repo, "fetch", "--progress", "--prune", "origin")
+}
+
+// disableAutoGC stops fetches from running gc or maintenance in repo. Those could prune objects
+// dependents borrow that the dependent refs don't reach yet, e.g. after a force-push upstream; gc
+// only runs in gcRepo, once the dependents' objects are protected.
+func disableAutoGC(repo string) error {
+	for _, setting := range [][2]string{{"gc.auto", "0"}, {"maintenance.auto", "false"}} {
+		if _, err := git.RunGitCommandOutput("--git-dir="+repo, "config", setting[0], setting[1]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", setting[0], err)
+		}
+	}
+	return nil
+}
+
+// dependents returns the repositories registered as borrowing objects from repo
+func dependents(repo string) []string {
+	output, err := git.RunGitCommandOutput("--git-dir="+repo, "config", "--get-all", dependentKey)
+	if err != nil || output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// forget unregisters dependent and deletes the refs kept for it
+func forget(repo, dependent, ref string) error {
+	refs, err := git.RunGitCommandOutput("--git-dir="+repo, "for-each-ref", "--format=%(refname)", ref+"/")
+	if err != nil {
+		return err
+	}
+	for _, name := range strings.Fields(refs) {
+		if _, err := git.RunGitCommandOutput("--git-dir="+repo, "update-ref", "-d", name); err != nil {
+			return err
+		}
+	}
+	_, err = git.RunGitCommandOutput("--git-dir="+repo, "config", "--fixed-value", "--unset-all", dependentKey, dependent)
+	return err
+}
+
+// borrowsFrom reports whether the repository at gitDir still lists repo in its alternates
+func borrowsFrom(gitDir, repo string) bool {
+	alternates, err := os.ReadFile(filepath.Join(gitDir, "objects", "info", "alternates"))
+	if err != nil {
+		return false
+	}
+	objects := resolve(filepath.Join(repo, "objects"))
+	for _, line := range strings.Split(string(alternates), "\n") {
+		if line = strings.TrimSpace(line); line != "" && resolve(line) == objects {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns path with symlinks resolved, or cleaned when it can't be resolved
+func resolve(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// dependentID returns a stable ref-safe identifier for a dependent's path
+func dependentID(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:8])
+}
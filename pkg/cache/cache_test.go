@@ -0,0 +1,160 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSource creates a repository with commits on main and feature
+func newSource(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+	gittest.Run(t, src, "checkout", "-b", "feature")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "feature commit")
+	gittest.Run(t, src, "checkout", "main")
+	return src
+}
+
+// newDependent clones src borrowing objects from the cached repository at path
+func newDependent(t *testing.T, c *Cache, path, src string) string {
+	t.Helper()
+	dependent := filepath.Join(t.TempDir(), ".bare")
+	gittest.Run(t, filepath.Dir(dependent), "clone", "--bare", "--quiet", "--reference", path, src, dependent)
+	require.NoError(t, c.Register(path, dependent))
+	return dependent
+}
+
+func TestCache_Ensure(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "group/team", "repo")
+	assert.Equal(t, filepath.Join(c.Dir, "example.com", "group", "team", "repo.git"), path)
+
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+	assert.NotEmpty(t, gittest.Run(t, path, "--git-dir="+path, "rev-parse", "--verify", "refs/heads/feature"))
+
+	// A second call fetches new commits instead of cloning again
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "second commit")
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+	assert.Equal(t, gittest.Run(t, src, "rev-parse", "main"), gittest.Run(t, path, "--git-dir="+path, "rev-parse", "main"))
+
+	repos, err := c.Repos()
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, repos)
+}
+
+func TestCache_DisablesAutoGC(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "org", "repo")
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+	assert.Equal(t, "0", gittest.Run(t, path, "--git-dir="+path, "config", "gc.auto"))
+	assert.Equal(t, "false", gittest.Run(t, path, "--git-dir="+path, "config", "maintenance.auto"))
+
+	// Repositories cached before the settings existed get them on their next update
+	gittest.Run(t, path, "--git-dir="+path, "config", "--unset", "gc.auto")
+	gittest.Run(t, path, "--git-dir="+path, "config", "--unset", "maintenance.auto")
+	require.NoError(t, c.Update(io.Discard))
+	assert.Equal(t, "0", gittest.Run(t, path, "--git-dir="+path, "config", "gc.auto"))
+	assert.Equal(t, "false", gittest.Run(t, path, "--git-dir="+path, "config", "maintenance.auto"))
+}
+
+func TestCache_Ensure_WaitsForLock(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "org", "repo")
+
+	// Another process holding the lock is simulated by a separate Cache on the same directory
+	unlock, err := New(c.Dir).lock(path)
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- c.Ensure(io.Discard, path, src) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Ensure finished while the cache was locked: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.NoDirExists(t, path)
+
+	unlock()
+	require.NoError(t, <-done)
+	assert.DirExists(t, path)
+}
+
+func TestCache_Register(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "org", "repo")
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+
+	dependent := newDependent(t, c, path, src)
+	require.NoError(t, c.Register(path, dependent))
+
+	assert.Equal(t, []string{dependent}, dependents(path))
+	assert.True(t, borrowsFrom(dependent, path))
+}
+
+func TestCache_GC(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "org", "repo")
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+	dependent := newDependent(t, c, path, src)
+	feature := gittest.Run(t, src, "rev-parse", "feature")
+
+	// Deleting the branch upstream drops it from the cache, but the dependent still needs its objects
+	gittest.Run(t, src, "branch", "-D", "feature")
+	require.NoError(t, c.Update(io.Discard))
+	require.NoError(t, c.GC(io.Discard))
+
+	kept := gittest.Run(t, path, "--git-dir="+path, "rev-parse", dependentRefs+dependentID(dependent)+"/heads/feature")
+	assert.Equal(t, feature, kept)
+	gittest.Run(t, dependent, "--git-dir="+dependent, "fsck", "--no-dangling")
+}
+
+func TestCache_GC_RemovesUnusedRepositories(t *testing.T) {
+	src := newSource(t)
+	c := New(t.TempDir())
+	path := c.Path("example.com", "org", "repo")
+	require.NoError(t, c.Ensure(io.Discard, path, src))
+	used := newDependent(t, c, path, src)
+	removed := newDependent(t, c, path, src)
+	require.NoError(t, os.RemoveAll(removed))
+
+	require.NoError(t, c.GC(io.Discard))
+	assert.Equal(t, []string{used}, dependents(path))
+
+	require.NoError(t, os.RemoveAll(used))
+	require.NoError(t, c.GC(io.Discard))
+	assert.NoDirExists(t, path)
+}
+
+func TestCache_Repos_Missing(t *testing.T) {
+	repos, err := New(filepath.Join(t.TempDir(), "missing")).Repos()
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+}
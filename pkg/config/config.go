@@ -17,6 +17,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -52,18 +53,326 @@ func ParseCloneMethod(s string) (CloneMethod, error) {
 	return method, nil
 }
 
+// SigningFormat is the kind of key commits are signed with
+type SigningFormat string
+
+const (
+	// SigningFormatGPG signs with a GPG key, named by its ID or fingerprint
+	SigningFormatGPG SigningFormat = "gpg"
+	// SigningFormatSSH signs with an SSH key, named by the path of the key
+	SigningFormatSSH SigningFormat = "ssh"
+)
+
+// String returns the string representation of the signing format
+func (f SigningFormat) String() string {
+	return string(f)
+}
+
+// IsValid checks if the signing format is valid
+func (f SigningFormat) IsValid() bool {
+	return f == SigningFormatGPG || f == SigningFormatSSH
+}
+
+// ParseSigningFormat parses a string into a SigningFormat
+func ParseSigningFormat(s string) (SigningFormat, error) {
+	format := SigningFormat(strings.ToLower(s))
+	if !format.IsValid() {
+		return "", fmt.Errorf("invalid signing format: %s (valid options: gpg, ssh)", s)
+	}
+	return format, nil
+}
+
+// SelectorBackend represents the implementation used for interactive selection
+type SelectorBackend string
+
+const (
+	// SelectorAuto uses the TUI when attached to a terminal and the prompt otherwise
+	SelectorAuto SelectorBackend = "auto"
+	// SelectorTUI uses the built-in Bubble Tea selector
+	SelectorTUI SelectorBackend = "tui"
+	// SelectorFZF uses the external fzf fuzzy finder
+	SelectorFZF SelectorBackend = "fzf"
+	// SelectorSK uses the external skim fuzzy finder
+	SelectorSK SelectorBackend = "sk"
+	// SelectorPrompt uses a plain numbered prompt on stdin
+	SelectorPrompt SelectorBackend = "prompt"
+)
+
+// String returns the string representation of the selector backend
+func (s SelectorBackend) String() string {
+	return string(s)
+}
+
+// IsValid checks if the selector backend is valid
+func (s SelectorBackend) IsValid() bool {
+	switch s {
+	case SelectorAuto, SelectorTUI, SelectorFZF, SelectorSK, SelectorPrompt:
+		return true
+	}
+	return false
+}
+
+// ParseSelectorBackend parses a string into a SelectorBackend
+func ParseSelectorBackend(s string) (SelectorBackend, error) {
+	backend := SelectorBackend(strings.ToLower(s))
+	if !backend.IsValid() {
+		return "", fmt.Errorf("invalid selector backend: %s (valid options: auto, tui, fzf, sk, prompt)", s)
+	}
+	return backend, nil
+}
+
+// Selector theme presets
+const (
+	// ThemeDefault is the standard color scheme
+	ThemeDefault = "default"
+	// ThemeHighContrast uses bright colors plus bold and underline so selection never relies on hue alone
+	ThemeHighContrast = "high-contrast"
+	// ThemeNone disables colors entirely; it is always used when NO_COLOR is set
+	ThemeNone = "none"
+)
+
+// Selector key map presets
+const (
+	// KeymapDefault uses arrow keys plus j/k
+	KeymapDefault = "default"
+	// KeymapVim adds ctrl+j/ctrl+k and esc to quit
+	KeymapVim = "vim"
+	// KeymapEmacs uses ctrl+n/ctrl+p to move and ctrl+g to quit
+	KeymapEmacs = "emacs"
+)
+
+// SelectorConfig represents configuration for the interactive selector
+type SelectorConfig struct {
+	Backend SelectorBackend `yaml:"backend,omitempty"`
+	// Theme is one of the theme presets; Colors override individual colors of the preset
+	Theme  string         `yaml:"theme,omitempty"`
+	Colors SelectorColors `yaml:"colors,omitempty"`
+	// Height is the number of lines used by the list, capped to the terminal height
+	Height int `yaml:"height,omitempty"`
+	// Padding is the left indentation of list items
+	Padding *int `yaml:"padding,omitempty"`
+	// Keymap is one of the key map presets; Keys override individual bindings of the preset
+	Keymap string       `yaml:"keymap,omitempty"`
+	Keys   SelectorKeys `yaml:"keys,omitempty"`
+}
+
+// SelectorColors overrides theme colors. Values are ANSI color numbers (e.g. "170") or hex codes (e.g. "#ff8800")
+type SelectorColors struct {
+	Selected string `yaml:"selected,omitempty"`
+	Branch   string `yaml:"branch,omitempty"`
+	Clean    string `yaml:"clean,omitempty"`
+	Dirty    string `yaml:"dirty,omitempty"`
+	Border   string `yaml:"border,omitempty"`
+}
+
+// SelectorKeys overrides key bindings. Each entry lists the keys bound to the action, e.g. ["ctrl+n", "down"]
+type SelectorKeys struct {
+	Up        []string `yaml:"up,omitempty"`
+	Down      []string `yaml:"down,omitempty"`
+	Choose    []string `yaml:"choose,omitempty"`
+	Quit      []string `yaml:"quit,omitempty"`
+	Filter    []string `yaml:"filter,omitempty"`
+	Toggle    []string `yaml:"toggle,omitempty"`
+	ToggleAll []string `yaml:"toggle_all,omitempty"`
+}
+
+// WorktreeLayout represents where new worktrees are placed relative to the repository
+type WorktreeLayout string
+
+const (
+	// WorktreeLayoutNested places worktrees inside the repository root, or in .worktrees for standard clones
+	WorktreeLayoutNested WorktreeLayout = "nested"
+	// WorktreeLayoutSibling places worktrees in a <repo>.worktrees directory next to the repository
+	WorktreeLayoutSibling WorktreeLayout = "sibling"
+	// WorktreeLayoutCustom places worktrees at the path rendered from WorktreesConfig.Path
+	WorktreeLayoutCustom WorktreeLayout = "custom"
+)
+
+// String returns the string representation of the worktree layout
+func (l WorktreeLayout) String() string {
+	return string(l)
+}
+
+// IsValid checks if the worktree layout is valid
+func (l WorktreeLayout) IsValid() bool {
+	return l == WorktreeLayoutNested || l == WorktreeLayoutSibling || l == WorktreeLayoutCustom
+}
+
+// ParseWorktreeLayout parses a string into a WorktreeLayout
+func ParseWorktreeLayout(s string) (WorktreeLayout, error) {
+	layout := WorktreeLayout(strings.ToLower(s))
+	if !layout.IsValid() {
+		return "", fmt.Errorf("invalid worktree layout: %s (valid options: nested, sibling, custom)", s)
+	}
+	return layout, nil
+}
+
+// DefaultStandardWorktreeDir is where worktrees of standard (non-bare) clones are created by default
+const DefaultStandardWorktreeDir = "../{{.Repo}}.worktrees"
+
+// WorktreesConfig configures where worktrees are created
+type WorktreesConfig struct {
+	// Layout selects where new worktrees are placed. When empty, the bare layout nests worktrees
+	// in the repository root and standard clones use Dir.
+	Layout WorktreeLayout `yaml:"layout,omitempty"`
+	// Path is the template used by the custom layout, e.g. ~/wt/{{.Repo}}/{{.Branch}}
+	Path string `yaml:"path,omitempty"`
+	// Dir is the directory worktrees of standard (non-bare) clones are created in. It is a template
+	// where {{.Repo}} is the name of the clone's directory; relative paths are resolved against the clone.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// CacheConfig configures the shared object cache setup clones borrow objects from
+type CacheConfig struct {
+	// Enabled makes setup use the cache for every repository it clones from a host
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Dir is where cached repositories are kept. Defaults to worktree/objects in the user cache directory.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// CacheDir returns the absolute path of the shared object cache
+func (c CacheConfig) CacheDir() (string, error) {
+	if c.Dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user cache directory: %w", err)
+		}
+		return filepath.Join(cacheDir, "worktree", "objects"), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return resolvePath(c.Dir, cwd)
+}
+
+// WorktreePathData holds the variables available to worktree path templates
+type WorktreePathData struct {
+	// Root is the repository root
+	Root string
+	// Repo is the name of the repository root directory
+	Repo string
+	// Branch is the name of the new worktree's branch
+	Branch string
+}
+
 // HostConfig represents configuration for a specific host/domain
 type HostConfig struct {
 	Account     string      `yaml:"account"`
 	CloneMethod CloneMethod `yaml:"clone_method,omitempty"`
+	// Port is the SSH port, e.g. 7999 for Bitbucket Server. It is used in generated SSH URLs and made
+	// available to URL templates.
+	Port string `yaml:"port,omitempty"`
+	// SSHUser is the user in generated SSH URLs, defaulting to git
+	SSHUser string `yaml:"ssh_user,omitempty"`
+	// SSHHost replaces the domain in generated SSH URLs, usually an ssh_config Host alias such as
+	// github-work that selects a key
+	SSHHost string `yaml:"ssh_host,omitempty"`
+	// IdentityFile is the private key SSH clones and fetches authenticate with. When it is empty the
+	// IdentityFile of the host's ssh_config entry, the SSH agent and the default keys are tried.
+	IdentityFile string `yaml:"identity_file,omitempty"`
+	// PassphraseEnv names the environment variable holding the passphrase of the identity file
+	PassphraseEnv string `yaml:"passphrase_env,omitempty"`
+	// PassphraseFile is a file holding the passphrase, used when PassphraseEnv is unset or empty
+	PassphraseFile string `yaml:"passphrase_file,omitempty"`
+	// HostKeyFingerprint pins the SHA256 fingerprint of the host's SSH key. An unknown key is only
+	// added to known_hosts when it matches.
+	HostKeyFingerprint string `yaml:"host_key_fingerprint,omitempty"`
+	// URLs holds URL templates per clone method, overriding the default github-style URLs
+	URLs map[CloneMethod]URLTemplates `yaml:"urls,omitempty"`
+	// TokenEnv names the environment variable holding an access token for HTTPS clones and fetches
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// TokenFile is a file holding an access token, used when TokenEnv is unset or empty
+	TokenFile string `yaml:"token_file,omitempty"`
+	// UserName and UserEmail are the commit identity of repositories set up from the host
+	UserName  string `yaml:"user_name,omitempty"`
+	UserEmail string `yaml:"user_email,omitempty"`
+	// SigningKey is the key commits are signed with: a GPG key ID, or the path of an SSH key
+	SigningKey    string        `yaml:"signing_key,omitempty"`
+	SigningFormat SigningFormat `yaml:"signing_format,omitempty"`
+	// SignCommits turns commit signing on or off. The user's global setting applies when it is unset.
+	SignCommits *bool `yaml:"sign_commits,omitempty"`
+	// Profiles are alternative identities on the host, e.g. a work account used for some orgs
+	Profiles []Profile `yaml:"profiles,omitempty"`
 }
 
+// Profile is an alternative identity on a host. The settings it sets override the host's for
+// repositories in the namespaces it matches, or when it is chosen by name.
+type Profile struct {
+	Name string `yaml:"name"`
+	// Orgs are path patterns such as acme-* matched against a repository's namespace and its top-level
+	// group. The first profile that matches is used.
+	Orgs       []string `yaml:"orgs,omitempty"`
+	HostConfig `yaml:",inline"`
+}
+
+// Matches reports whether the profile applies to repositories in namespace
+func (p Profile) Matches(namespace string) bool {
+	namespace = strings.Trim(namespace, "/")
+	top, _, _ := strings.Cut(namespace, "/")
+	for _, pattern := range p.Orgs {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, top); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// overlay returns h with the settings p sets replacing h's
+func (h HostConfig) overlay(p HostConfig) HostConfig {
+	for _, field := range []struct{ dst, src *string }{
+		{&h.Account, &p.Account},
+		{&h.Port, &p.Port},
+		{&h.SSHUser, &p.SSHUser},
+		{&h.SSHHost, &p.SSHHost},
+		{&h.IdentityFile, &p.IdentityFile},
+		{&h.PassphraseEnv, &p.PassphraseEnv},
+		{&h.PassphraseFile, &p.PassphraseFile},
+		{&h.HostKeyFingerprint, &p.HostKeyFingerprint},
+		{&h.TokenEnv, &p.TokenEnv},
+		{&h.TokenFile, &p.TokenFile},
+		{&h.UserName, &p.UserName},
+		{&h.UserEmail, &p.UserEmail},
+		{&h.SigningKey, &p.SigningKey},
+	} {
+		if *field.src != "" {
+			*field.dst = *field.src
+		}
+	}
+	if p.CloneMethod != "" {
+		h.CloneMethod = p.CloneMethod
+	}
+	if p.SigningFormat != "" {
+		h.SigningFormat = p.SigningFormat
+	}
+	if p.SignCommits != nil {
+		h.SignCommits = p.SignCommits
+	}
+	if p.URLs != nil {
+		h.URLs = p.URLs
+	}
+	return h
+}
+
+// DefaultTokenUsername is sent with an access token when no account is configured. GitHub and
+// GitLab accept any username alongside a token.
+const DefaultTokenUsername = "x-access-token"
+
 // Config represents the application configuration
 type Config struct {
 	// Legacy field for backward compatibility - will be migrated to Hosts
 	Accounts map[string]string `yaml:"accounts,omitempty"`
 	// New field for host configurations
 	Hosts map[string]HostConfig `yaml:"hosts,omitempty"`
+	// Selector configures interactive worktree selection
+	Selector SelectorConfig `yaml:"selector,omitempty"`
+	// Worktrees configures where worktrees are created
+	Worktrees WorktreesConfig `yaml:"worktrees,omitempty"`
+	// Cache configures the shared object cache
+	Cache CacheConfig `yaml:"cache,omitempty"`
 }
 
 // DefaultConfig returns a config with sensible defaults
@@ -193,17 +502,13 @@ func (c *Config) SetAccount(domain, account string) {
 		c.Hosts = make(map[string]HostConfig)
 	}
 
-	// Preserve existing clone method if it exists, otherwise default to HTTP
-	existing := c.Hosts[domain]
-	cloneMethod := existing.CloneMethod
-	if cloneMethod == "" {
-		cloneMethod = CloneMethodHTTP
-	}
-
-	c.Hosts[domain] = HostConfig{
-		Account:     account,
-		CloneMethod: cloneMethod,
+	// Preserve the rest of the host configuration, defaulting the clone method to HTTP
+	host := c.Hosts[domain]
+	host.Account = account
+	if host.CloneMethod == "" {
+		host.CloneMethod = CloneMethodHTTP
 	}
+	c.Hosts[domain] = host
 }
 
 // ListAccounts returns all configured domain-account pairs
@@ -239,6 +544,81 @@ func (c *Config) GetHostConfig(domain string) HostConfig {
 	}
 }
 
+// SetHostConfig replaces the configuration of the given domain
+func (c *Config) SetHostConfig(domain string, host HostConfig) {
+	// Handle empty domain (default to github.com)
+	if domain == "" {
+		domain = "github.com"
+	}
+
+	if c.Hosts == nil {
+		c.Hosts = make(map[string]HostConfig)
+	}
+	c.Hosts[domain] = host
+}
+
+// ApplyProfile returns a copy of the configuration in which the settings of a profile of the domain
+// override the host's, along with the profile's name. The profile is the one called name, or when
+// name is empty the first whose orgs match namespace. The configuration is returned unchanged with
+// an empty name when no profile matches.
+func (c *Config) ApplyProfile(domain, namespace, name string) (*Config, string, error) {
+	// Handle empty domain (default to github.com)
+	if domain == "" {
+		domain = "github.com"
+	}
+
+	host := c.GetHostConfig(domain)
+	var profile *Profile
+	for i := range host.Profiles {
+		p := &host.Profiles[i]
+		if (name != "" && p.Name == name) || (name == "" && p.Matches(namespace)) {
+			profile = p
+			break
+		}
+	}
+	if profile == nil {
+		if name != "" {
+			return nil, "", fmt.Errorf("no profile %q configured for %s", name, domain)
+		}
+		return c, "", nil
+	}
+
+	applied := *c
+	applied.Hosts = make(map[string]HostConfig, len(c.Hosts))
+	for d, h := range c.Hosts {
+		applied.Hosts[d] = h
+	}
+	applied.Hosts[domain] = host.overlay(profile.HostConfig)
+	return &applied, profile.Name, nil
+}
+
+// SetProfile adds the profile to the domain, replacing the profile with the same name
+func (c *Config) SetProfile(domain string, profile Profile) {
+	host := c.GetHostConfig(domain)
+	for i := range host.Profiles {
+		if host.Profiles[i].Name == profile.Name {
+			host.Profiles[i] = profile
+			c.SetHostConfig(domain, host)
+			return
+		}
+	}
+	host.Profiles = append(host.Profiles, profile)
+	c.SetHostConfig(domain, host)
+}
+
+// RemoveProfile removes the named profile from the domain, reporting whether it existed
+func (c *Config) RemoveProfile(domain, name string) bool {
+	host := c.GetHostConfig(domain)
+	for i := range host.Profiles {
+		if host.Profiles[i].Name == name {
+			host.Profiles = append(host.Profiles[:i], host.Profiles[i+1:]...)
+			c.SetHostConfig(domain, host)
+			return true
+		}
+	}
+	return false
+}
+
 // GetCloneMethod returns the clone method for the given domain
 func (c *Config) GetCloneMethod(domain string) CloneMethod {
 	config := c.GetHostConfig(domain)
@@ -259,14 +639,120 @@ func (c *Config) SetCloneMethod(domain string, method CloneMethod) {
 		c.Hosts = make(map[string]HostConfig)
 	}
 
-	// Preserve existing account if it exists
-	existing := c.Hosts[domain]
-	account := existing.Account
+	// Preserve the rest of the host configuration
+	host := c.Hosts[domain]
+	host.CloneMethod = method
+	c.Hosts[domain] = host
+}
+
+// SetToken sets where the access token for the given domain is read from. Either may be empty.
+func (c *Config) SetToken(domain, env, file string) {
+	// Handle empty domain (default to github.com)
+	if domain == "" {
+		domain = "github.com"
+	}
+
+	if c.Hosts == nil {
+		c.Hosts = make(map[string]HostConfig)
+	}
+
+	// Preserve the rest of the host configuration
+	host := c.Hosts[domain]
+	host.TokenEnv = env
+	host.TokenFile = file
+	c.Hosts[domain] = host
+}
+
+// GetToken returns the username and access token for HTTPS requests to the given domain, read from
+// the configured environment variable or token file. The token is empty when neither is configured.
+func (c *Config) GetToken(domain string) (username, token string, err error) {
+	host := c.GetHostConfig(domain)
+	token, err = readSecret(host.TokenEnv, host.TokenFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read token file for %s: %w", domain, err)
+	}
+	if token == "" {
+		return "", "", nil
+	}
+
+	username = host.Account
+	if username == "" {
+		username = DefaultTokenUsername
+	}
+	return username, token, nil
+}
 
-	c.Hosts[domain] = HostConfig{
-		Account:     account,
-		CloneMethod: method,
+// GetSSHIdentity returns the identity file configured for SSH connections to the given domain with
+// ~/ expanded, and its passphrase. Both are empty when they aren't configured.
+func (c *Config) GetSSHIdentity(domain string) (identityFile, passphrase string, err error) {
+	host := c.GetHostConfig(domain)
+	if host.IdentityFile == "" {
+		return "", "", nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	if identityFile, err = resolvePath(host.IdentityFile, cwd); err != nil {
+		return "", "", err
+	}
+	if passphrase, err = readSecret(host.PassphraseEnv, host.PassphraseFile); err != nil {
+		return "", "", fmt.Errorf("failed to read passphrase file for %s: %w", domain, err)
+	}
+	return identityFile, passphrase, nil
+}
+
+// GetSigningKey returns the key commits to the domain's repositories are signed with. SSH key paths
+// are resolved like identity files; GPG key IDs and literal SSH keys are returned as they are.
+func (c *Config) GetSigningKey(domain string) (string, error) {
+	host := c.GetHostConfig(domain)
+	if host.SigningFormat != SigningFormatSSH || host.SigningKey == "" || strings.HasPrefix(host.SigningKey, "key::") {
+		return host.SigningKey, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
 	}
+	return resolvePath(host.SigningKey, cwd)
+}
+
+// readSecret returns the trimmed value of the environment variable env, falling back to the contents
+// of file when the variable is unset or empty. Either may be empty.
+func readSecret(env, file string) (string, error) {
+	if env != "" {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			return value, nil
+		}
+	}
+	if file == "" {
+		return "", nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	path, err := resolvePath(file, cwd)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// GetSelectorBackend returns the configured selector backend, defaulting to auto
+func (c *Config) GetSelectorBackend() SelectorBackend {
+	if c.Selector.Backend == "" {
+		return SelectorAuto
+	}
+	return c.Selector.Backend
+}
+
+// SetSelectorBackend sets the selector backend
+func (c *Config) SetSelectorBackend(backend SelectorBackend) {
+	c.Selector.Backend = backend
 }
 
 // ListHosts returns all configured hosts with their full configuration
@@ -284,36 +770,74 @@ func (c *Config) ListHosts() map[string]HostConfig {
 	return result
 }
 
-// GenerateRepositoryURL generates the appropriate repository URL based on the clone method
-func (c *Config) GenerateRepositoryURL(domain, org, repo string) string {
-	cloneMethod := c.GetCloneMethod(domain)
+// SetWorktreeLayout sets the worktree layout and, for the custom layout, its path template
+func (c *Config) SetWorktreeLayout(layout WorktreeLayout, path string) {
+	c.Worktrees.Layout = layout
+	c.Worktrees.Path = path
+}
 
-	switch cloneMethod {
-	case CloneMethodSSH:
-		return fmt.Sprintf("git@%s:%s/%s.git", domain, org, repo)
-	case CloneMethodHTTP:
-		fallthrough
-	default:
-		return fmt.Sprintf("https://%s/%s/%s.git", domain, org, repo)
+// SetCache enables or disables the shared object cache, keeping it in dir when dir is not empty
+func (c *Config) SetCache(enabled bool, dir string) {
+	c.Cache.Enabled = enabled
+	if dir != "" {
+		c.Cache.Dir = dir
 	}
 }
 
-// GenerateUserRepositoryURL generates a repository URL for a user's fork
-func (c *Config) GenerateUserRepositoryURL(domain, repo string) string {
-	account := c.GetAccount(domain)
-	if account == "" {
-		// Fallback to HTTP with empty account - this will likely fail but preserves existing behavior
-		return fmt.Sprintf("https://%s//%s.git", domain, repo)
+// WorktreePathTemplate returns the template new worktree paths of the repository are rendered from.
+// Relative results are resolved against the repository root by RenderWorktreePath.
+func (c *Config) WorktreePathTemplate(standard bool) (string, error) {
+	switch c.Worktrees.Layout {
+	case WorktreeLayoutNested:
+		if standard {
+			return ".worktrees/{{.Branch}}", nil
+		}
+		return "{{.Branch}}", nil
+	case WorktreeLayoutSibling:
+		return "../{{.Repo}}.worktrees/{{.Branch}}", nil
+	case WorktreeLayoutCustom:
+		if c.Worktrees.Path == "" {
+			return "", fmt.Errorf("the custom worktree layout requires a path template")
+		}
+		return c.Worktrees.Path, nil
+	case "":
+		if !standard {
+			return "{{.Branch}}", nil
+		}
+		dir := c.Worktrees.Dir
+		if dir == "" {
+			dir = DefaultStandardWorktreeDir
+		}
+		return dir + "/{{.Branch}}", nil
+	default:
+		return "", fmt.Errorf("invalid worktree layout: %s (valid options: nested, sibling, custom)", c.Worktrees.Layout)
 	}
+}
 
-	cloneMethod := c.GetCloneMethod(domain)
+// RenderWorktreePath renders a worktree path template for a branch of the repository at root
+func RenderWorktreePath(tmpl, root, branch string) (string, error) {
+	rendered, err := renderTemplate("worktree path", tmpl, WorktreePathData{
+		Root:   root,
+		Repo:   filepath.Base(root),
+		Branch: branch,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolvePath(rendered, root)
+}
 
-	switch cloneMethod {
-	case CloneMethodSSH:
-		return fmt.Sprintf("git@%s:%s/%s.git", domain, account, repo)
-	case CloneMethodHTTP:
-		fallthrough
-	default:
-		return fmt.Sprintf("https://%s/%s/%s.git", domain, account, repo)
+// resolvePath expands ~/ and resolves relative paths against root
+func resolvePath(path, root string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
 	}
+	return filepath.Clean(path), nil
 }
@@ -63,6 +63,41 @@ func TestCloneMethod(t *testing.T) {
 	})
 }
 
+func TestSelectorBackend(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected SelectorBackend
+		hasError bool
+	}{
+		{"auto", SelectorAuto, false},
+		{"tui", SelectorTUI, false},
+		{"FZF", SelectorFZF, false}, // case insensitive
+		{"sk", SelectorSK, false},
+		{"prompt", SelectorPrompt, false},
+		{"dialog", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseSelectorBackend(tt.input)
+			if tt.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("defaults to auto", func(t *testing.T) {
+		cfg := DefaultConfig()
+		assert.Equal(t, SelectorAuto, cfg.GetSelectorBackend())
+		cfg.SetSelectorBackend(SelectorFZF)
+		assert.Equal(t, SelectorFZF, cfg.GetSelectorBackend())
+	})
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -296,6 +331,20 @@ func TestConfig_GenerateRepositoryURL(t *testing.T) {
 				Account:     "corpuser",
 				CloneMethod: CloneMethodSSH,
 			},
+			"gitlab.company.com": {
+				Account:     "corpuser",
+				CloneMethod: CloneMethodSSH,
+			},
+			"bitbucket.company.com": {
+				Account:     "corpuser",
+				CloneMethod: CloneMethodSSH,
+				Port:        "7999",
+			},
+			"work-github": {
+				Account:     "corpuser",
+				CloneMethod: CloneMethodSSH,
+				SSHUser:     "deploy",
+			},
 		},
 	}
 
@@ -327,11 +376,40 @@ func TestConfig_GenerateRepositoryURL(t *testing.T) {
 			repo:     "myrepo",
 			expected: "https://gitlab.com/myorg/myrepo.git",
 		},
+		{
+			name:     "GitLab subgroups over HTTP",
+			domain:   "gitlab.com",
+			org:      "group/subgroup/team",
+			repo:     "myrepo",
+			expected: "https://gitlab.com/group/subgroup/team/myrepo.git",
+		},
+		{
+			name:     "GitLab subgroups over SSH",
+			domain:   "gitlab.company.com",
+			org:      "/group/subgroup/",
+			repo:     "myrepo",
+			expected: "git@gitlab.company.com:group/subgroup/myrepo.git",
+		},
+		{
+			name:     "SSH on a custom port",
+			domain:   "bitbucket.company.com",
+			org:      "proj",
+			repo:     "myrepo",
+			expected: "ssh://git@bitbucket.company.com:7999/proj/myrepo.git",
+		},
+		{
+			name:     "SSH with a custom user",
+			domain:   "work-github",
+			org:      "myorg",
+			repo:     "myrepo",
+			expected: "deploy@work-github:myorg/myrepo.git",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cfg.GenerateRepositoryURL(tt.domain, tt.org, tt.repo)
+			result, err := cfg.GenerateRepositoryURL(tt.domain, tt.org, tt.repo)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -379,12 +457,113 @@ func TestConfig_GenerateUserRepositoryURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cfg.GenerateUserRepositoryURL(tt.domain, tt.repo)
+			result, err := cfg.GenerateUserRepositoryURL(tt.domain, tt.repo)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestConfig_URLTemplates(t *testing.T) {
+	cfg := &Config{
+		Hosts: map[string]HostConfig{
+			"bitbucket.company.com": {
+				Account:     "jdoe",
+				CloneMethod: CloneMethodSSH,
+				Port:        "7999",
+				URLs: map[CloneMethod]URLTemplates{
+					CloneMethodSSH: {
+						Upstream: "ssh://git@{{.Host}}:{{.Port}}/scm/{{.Org}}/{{.Repo}}.git",
+						Fork:     "ssh://git@{{.Host}}:{{.Port}}/scm/~{{.Account}}/{{.Repo}}.git",
+					},
+				},
+			},
+			"dev.azure.com": {
+				CloneMethod: CloneMethodHTTP,
+				URLs: map[CloneMethod]URLTemplates{
+					CloneMethodHTTP: {Upstream: "https://{{.Host}}/{{.Org}}/_git/{{.Repo}}"},
+					// Templates for other clone methods are ignored
+					CloneMethodSSH: {Upstream: "git@ssh.{{.Host}}:v3/{{.Org}}/{{.Repo}}"},
+				},
+			},
+			"git.company.com": {
+				Account:     "jdoe",
+				CloneMethod: CloneMethodHTTP,
+				URLs: map[CloneMethod]URLTemplates{
+					CloneMethodHTTP: {Upstream: "https://{{.Host}}/git/{{.Org}}/{{.Repo}}.git"},
+				},
+			},
+			"broken.company.com": {
+				URLs: map[CloneMethod]URLTemplates{
+					CloneMethodHTTP: {Upstream: "https://{{.Host}}/{{.Unknown}}", Fork: "https://{{.Host"},
+				},
+			},
+		},
+	}
+
+	t.Run("Bitbucket Server", func(t *testing.T) {
+		upstream, err := cfg.GenerateRepositoryURL("bitbucket.company.com", "PROJ", "service")
+		require.NoError(t, err)
+		assert.Equal(t, "ssh://git@bitbucket.company.com:7999/scm/PROJ/service.git", upstream)
+
+		fork, err := cfg.GenerateUserRepositoryURL("bitbucket.company.com", "service")
+		require.NoError(t, err)
+		assert.Equal(t, "ssh://git@bitbucket.company.com:7999/scm/~jdoe/service.git", fork)
+		assert.True(t, cfg.HasForkTemplate("bitbucket.company.com"))
+	})
+
+	t.Run("Azure DevOps", func(t *testing.T) {
+		upstream, err := cfg.GenerateRepositoryURL("dev.azure.com", "org/project", "repo")
+		require.NoError(t, err)
+		assert.Equal(t, "https://dev.azure.com/org/project/_git/repo", upstream)
+	})
+
+	t.Run("path prefix with default fork URL", func(t *testing.T) {
+		upstream, err := cfg.GenerateRepositoryURL("git.company.com", "team", "repo")
+		require.NoError(t, err)
+		assert.Equal(t, "https://git.company.com/git/team/repo.git", upstream)
+
+		fork, err := cfg.GenerateUserRepositoryURL("git.company.com", "repo")
+		require.NoError(t, err)
+		assert.Equal(t, "https://git.company.com/jdoe/repo.git", fork)
+		assert.False(t, cfg.HasForkTemplate("git.company.com"))
+	})
+
+	t.Run("invalid templates", func(t *testing.T) {
+		_, err := cfg.GenerateRepositoryURL("broken.company.com", "team", "repo")
+		assert.ErrorContains(t, err, "failed to render URL template")
+
+		_, err = cfg.GenerateUserRepositoryURL("broken.company.com", "repo")
+		assert.ErrorContains(t, err, "invalid URL template")
+	})
+}
+
+func TestConfig_URLTemplatesRoundTrip(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "settings.yaml")
+	content := `hosts:
+  bitbucket.company.com:
+    account: jdoe
+    clone_method: ssh
+    port: 7999
+    urls:
+      ssh:
+        upstream: "ssh://git@{{.Host}}:{{.Port}}/scm/{{.Org}}/{{.Repo}}.git"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	cfg, err := LoadConfigFromPath(configPath)
+	require.NoError(t, err)
+
+	// Changing the account must not drop the rest of the host configuration
+	cfg.SetAccount("bitbucket.company.com", "someone")
+	host := cfg.GetHostConfig("bitbucket.company.com")
+	assert.Equal(t, "7999", host.Port)
+	assert.Equal(t, "ssh://git@{{.Host}}:{{.Port}}/scm/{{.Org}}/{{.Repo}}.git", host.URLs[CloneMethodSSH].Upstream)
+
+	cfg.SetCloneMethod("bitbucket.company.com", CloneMethodHTTP)
+	assert.Equal(t, "7999", cfg.GetHostConfig("bitbucket.company.com").Port)
+}
+
 func TestConfig_Migration(t *testing.T) {
 	t.Run("migrates legacy accounts to hosts", func(t *testing.T) {
 		cfg := &Config{
@@ -467,3 +646,294 @@ func TestLoadConfigFromPath_WithMigration(t *testing.T) {
 func WriteConfigContent(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
+
+func TestConfig_WorktreePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		worktree WorktreesConfig
+		standard bool
+		expected string
+		wantErr  bool
+	}{
+		{name: "bare layout nests by default", expected: "/src/project/feature/login"},
+		{name: "standard clone defaults to a sibling directory", standard: true, expected: "/src/project.worktrees/feature/login"},
+		{name: "standard clone with dir inside the clone", worktree: WorktreesConfig{Dir: ".worktrees"}, standard: true, expected: "/src/project/.worktrees/feature/login"},
+		{name: "standard clone with absolute dir", worktree: WorktreesConfig{Dir: "/fast/{{.Repo}}"}, standard: true, expected: "/fast/project/feature/login"},
+		{name: "nested", worktree: WorktreesConfig{Layout: WorktreeLayoutNested}, expected: "/src/project/feature/login"},
+		{name: "nested standard clone", worktree: WorktreesConfig{Layout: WorktreeLayoutNested}, standard: true, expected: "/src/project/.worktrees/feature/login"},
+		{name: "sibling", worktree: WorktreesConfig{Layout: WorktreeLayoutSibling}, expected: "/src/project.worktrees/feature/login"},
+		{name: "custom", worktree: WorktreesConfig{Layout: WorktreeLayoutCustom, Path: "~/wt/{{.Repo}}/{{.Branch}}"}, expected: filepath.Join(home, "wt", "project", "feature", "login")},
+		{name: "custom without path", worktree: WorktreesConfig{Layout: WorktreeLayoutCustom}, wantErr: true},
+		{name: "invalid layout", worktree: WorktreesConfig{Layout: "flat"}, wantErr: true},
+		{name: "invalid template", worktree: WorktreesConfig{Layout: WorktreeLayoutCustom, Path: "{{.Unknown}}"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Worktrees: tt.worktree}
+			tmpl, err := cfg.WorktreePathTemplate(tt.standard)
+			if err == nil {
+				var path string
+				path, err = RenderWorktreePath(tmpl, "/src/project", "feature/login")
+				if err == nil {
+					assert.Equal(t, tt.expected, path)
+				}
+			}
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseWorktreeLayout(t *testing.T) {
+	layout, err := ParseWorktreeLayout("Sibling")
+	require.NoError(t, err)
+	assert.Equal(t, WorktreeLayoutSibling, layout)
+
+	_, err = ParseWorktreeLayout("flat")
+	assert.ErrorContains(t, err, "invalid worktree layout")
+}
+
+func TestParseSigningFormat(t *testing.T) {
+	format, err := ParseSigningFormat("SSH")
+	require.NoError(t, err)
+	assert.Equal(t, SigningFormatSSH, format)
+
+	_, err = ParseSigningFormat("x509")
+	assert.ErrorContains(t, err, "invalid signing format")
+}
+
+func TestCacheConfig_CacheDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	userCache, err := os.UserCacheDir()
+	require.NoError(t, err)
+
+	dir, err := CacheConfig{}.CacheDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(userCache, "worktree", "objects"), dir)
+
+	dir, err = CacheConfig{Dir: "~/objects"}.CacheDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "objects"), dir)
+
+	cfg := DefaultConfig()
+	cfg.SetCache(true, "/srv/objects")
+	cfg.SetCache(false, "")
+	assert.Equal(t, CacheConfig{Dir: "/srv/objects"}, cfg.Cache)
+}
+
+func TestConfig_GetToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0600))
+	t.Setenv("WT_TEST_TOKEN", "")
+
+	cfg := DefaultConfig()
+	username, token, err := cfg.GetToken("github.com")
+	require.NoError(t, err)
+	assert.Empty(t, username)
+	assert.Empty(t, token)
+
+	// The file is used while the environment variable is empty
+	cfg.SetToken("gitlab.company.com", "WT_TEST_TOKEN", tokenFile)
+	username, token, err = cfg.GetToken("gitlab.company.com")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTokenUsername, username)
+	assert.Equal(t, "from-file", token)
+
+	t.Setenv("WT_TEST_TOKEN", "from-env")
+	cfg.SetAccount("gitlab.company.com", "jdoe")
+	username, token, err = cfg.GetToken("gitlab.company.com")
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe", username)
+	assert.Equal(t, "from-env", token)
+
+	cfg.SetToken("gitlab.company.com", "", filepath.Join(t.TempDir(), "missing"))
+	_, _, err = cfg.GetToken("gitlab.company.com")
+	assert.ErrorContains(t, err, "failed to read token file")
+}
+
+func TestConfig_GetSSHIdentity(t *testing.T) {
+	dir := t.TempDir()
+	passphraseFile := filepath.Join(dir, "passphrase")
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("from-file\n"), 0600))
+	t.Setenv("WT_TEST_PASSPHRASE", "")
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	identity, passphrase, err := cfg.GetSSHIdentity("github.com")
+	require.NoError(t, err)
+	assert.Empty(t, identity)
+	assert.Empty(t, passphrase)
+
+	cfg.SetHostConfig("github.com", HostConfig{
+		Account:        "jdoe",
+		IdentityFile:   "~/.ssh/id_work",
+		PassphraseEnv:  "WT_TEST_PASSPHRASE",
+		PassphraseFile: passphraseFile,
+	})
+	identity, passphrase, err = cfg.GetSSHIdentity("github.com")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".ssh", "id_work"), identity)
+	assert.Equal(t, "from-file", passphrase)
+
+	t.Setenv("WT_TEST_PASSPHRASE", "from-env")
+	_, passphrase, err = cfg.GetSSHIdentity("github.com")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", passphrase)
+
+	cfg.SetHostConfig("github.com", HostConfig{IdentityFile: "/keys/id_work", PassphraseFile: filepath.Join(dir, "missing")})
+	_, _, err = cfg.GetSSHIdentity("github.com")
+	assert.ErrorContains(t, err, "failed to read passphrase file")
+}
+
+func TestConfig_GetSigningKey(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	cfg.SetHostConfig("github.com", HostConfig{SigningKey: "~/.ssh/id_work.pub", SigningFormat: SigningFormatSSH})
+	cfg.SetHostConfig("gitlab.com", HostConfig{SigningKey: "3AA5C34371567BD2", SigningFormat: SigningFormatGPG})
+	cfg.SetHostConfig("example.com", HostConfig{SigningKey: "key::ssh-ed25519 AAAA", SigningFormat: SigningFormatSSH})
+
+	key, err := cfg.GetSigningKey("github.com")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".ssh", "id_work.pub"), key)
+
+	for domain, expected := range map[string]string{
+		"gitlab.com":    "3AA5C34371567BD2",
+		"example.com":   "key::ssh-ed25519 AAAA",
+		"bitbucket.org": "",
+	} {
+		key, err := cfg.GetSigningKey(domain)
+		require.NoError(t, err)
+		assert.Equal(t, expected, key, domain)
+	}
+}
+
+func TestProfile_Matches(t *testing.T) {
+	p := Profile{Name: "work", Orgs: []string{"acme-*", "platform"}}
+	assert.True(t, p.Matches("acme-payments"))
+	assert.True(t, p.Matches("platform"))
+	assert.True(t, p.Matches("platform/team/sub"), "the top-level group is matched too")
+	assert.False(t, p.Matches("acme"))
+	assert.False(t, p.Matches("jdoe"))
+	assert.False(t, Profile{Name: "manual"}.Matches("acme-payments"))
+}
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "settings.yaml")
+	content := `hosts:
+  github.com:
+    account: jdoe
+    clone_method: ssh
+    profiles:
+      - name: work
+        orgs: [acme-*]
+        account: jdoe-acme
+        ssh_host: github-work
+        token_env: ACME_TOKEN
+      - name: oss
+        orgs: [kubernetes, acme-oss]
+        clone_method: http
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+	cfg, err := LoadConfigFromPath(configPath)
+	require.NoError(t, err)
+
+	t.Run("the first profile matching the org", func(t *testing.T) {
+		applied, name, err := cfg.ApplyProfile("github.com", "acme-payments", "")
+		require.NoError(t, err)
+		assert.Equal(t, "work", name)
+		assert.Equal(t, "jdoe-acme", applied.GetAccount("github.com"))
+		assert.Equal(t, "ACME_TOKEN", applied.GetHostConfig("github.com").TokenEnv)
+
+		url, err := applied.GenerateRepositoryURL("github.com", "acme-payments", "api")
+		require.NoError(t, err)
+		assert.Equal(t, "git@github-work:acme-payments/api.git", url)
+		url, err = applied.GenerateUserRepositoryURL("github.com", "api")
+		require.NoError(t, err)
+		assert.Equal(t, "git@github-work:jdoe-acme/api.git", url)
+
+		// The loaded configuration is left alone
+		assert.Equal(t, "jdoe", cfg.GetAccount("github.com"))
+		assert.Empty(t, cfg.GetHostConfig("github.com").SSHHost)
+	})
+
+	t.Run("settings the profile doesn't set come from the host", func(t *testing.T) {
+		applied, name, err := cfg.ApplyProfile("github.com", "kubernetes", "")
+		require.NoError(t, err)
+		assert.Equal(t, "oss", name)
+		assert.Equal(t, "jdoe", applied.GetAccount("github.com"))
+		assert.Equal(t, CloneMethodHTTP, applied.GetCloneMethod("github.com"))
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		applied, name, err := cfg.ApplyProfile("github.com", "jdoe", "work")
+		require.NoError(t, err)
+		assert.Equal(t, "work", name)
+		assert.Equal(t, "jdoe-acme", applied.GetAccount("github.com"))
+	})
+
+	t.Run("no matching profile", func(t *testing.T) {
+		applied, name, err := cfg.ApplyProfile("github.com", "jdoe", "")
+		require.NoError(t, err)
+		assert.Empty(t, name)
+		assert.Same(t, cfg, applied)
+	})
+
+	t.Run("commit identity", func(t *testing.T) {
+		sign := true
+		cfg := DefaultConfig()
+		cfg.SetHostConfig("github.com", HostConfig{UserName: "Jane Doe", UserEmail: "jane@example.com", SignCommits: &sign})
+		cfg.SetProfile("github.com", Profile{Name: "work", Orgs: []string{"acme"}, HostConfig: HostConfig{
+			UserEmail:     "jane@acme.com",
+			SigningKey:    "~/.ssh/id_acme.pub",
+			SigningFormat: SigningFormatSSH,
+		}})
+
+		applied, _, err := cfg.ApplyProfile("github.com", "acme", "")
+		require.NoError(t, err)
+		host := applied.GetHostConfig("github.com")
+		assert.Equal(t, "Jane Doe", host.UserName)
+		assert.Equal(t, "jane@acme.com", host.UserEmail)
+		assert.Equal(t, "~/.ssh/id_acme.pub", host.SigningKey)
+		assert.Equal(t, SigningFormatSSH, host.SigningFormat)
+		require.NotNil(t, host.SignCommits)
+		assert.True(t, *host.SignCommits)
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		_, _, err := cfg.ApplyProfile("github.com", "acme-payments", "missing")
+		assert.ErrorContains(t, err, `no profile "missing" configured for github.com`)
+		_, _, err = cfg.ApplyProfile("gitlab.com", "group", "work")
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_SetProfile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SetAccount("github.com", "jdoe")
+	cfg.SetProfile("github.com", Profile{Name: "work", Orgs: []string{"acme-*"}})
+	cfg.SetProfile("github.com", Profile{Name: "oss", Orgs: []string{"kubernetes"}})
+	cfg.SetProfile("github.com", Profile{Name: "work", Orgs: []string{"acme"}, HostConfig: HostConfig{Account: "jdoe-acme"}})
+
+	host := cfg.GetHostConfig("github.com")
+	assert.Equal(t, "jdoe", host.Account)
+	require.Len(t, host.Profiles, 2)
+	assert.Equal(t, "work", host.Profiles[0].Name, "replacing a profile keeps its position")
+	assert.Equal(t, []string{"acme"}, host.Profiles[0].Orgs)
+	assert.Equal(t, "jdoe-acme", host.Profiles[0].Account)
+
+	assert.True(t, cfg.RemoveProfile("github.com", "work"))
+	assert.False(t, cfg.RemoveProfile("github.com", "work"))
+	assert.False(t, cfg.RemoveProfile("gitlab.com", "oss"))
+	require.Len(t, cfg.GetHostConfig("github.com").Profiles, 1)
+	assert.Equal(t, "oss", cfg.GetHostConfig("github.com").Profiles[0].Name)
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// URLTemplates are Go templates used to build repository URLs for a host, e.g.
+// "ssh://git@{{.Host}}:{{.Port}}/scm/{{.Org}}/{{.Repo}}.git". See URLTemplateData for the available variables.
+type URLTemplates struct {
+	// Upstream builds the URL of the original repository
+	Upstream string `yaml:"upstream,omitempty"`
+	// Fork builds the URL of the user's fork
+	Fork string `yaml:"fork,omitempty"`
+}
+
+// URLTemplateData holds the variables available to URL templates
+type URLTemplateData struct {
+	// Host is the domain of the host
+	Host string
+	// Org is the namespace of the repository; for forks it is the account
+	Org string
+	// Repo is the repository name without the .git suffix
+	Repo string
+	// Account is the account configured for the host
+	Account string
+	// Port is the port configured for the host
+	Port string
+}
+
+// GenerateRepositoryURL generates the appropriate repository URL based on the clone method.
+// The namespace may contain several segments, e.g. a GitLab group/subgroup path.
+func (c *Config) GenerateRepositoryURL(domain, namespace, repo string) (string, error) {
+	host := c.GetHostConfig(domain)
+	cloneMethod := c.GetCloneMethod(domain)
+	namespace = strings.Trim(namespace, "/")
+
+	if tmpl := host.URLs[cloneMethod].Upstream; tmpl != "" {
+		return renderURL(tmpl, URLTemplateData{
+			Host:    domain,
+			Org:     namespace,
+			Repo:    repo,
+			Account: host.Account,
+			Port:    host.Port,
+		})
+	}
+
+	switch cloneMethod {
+	case CloneMethodSSH:
+		return sshURL(domain, host, namespace+"/"+repo), nil
+	case CloneMethodHTTP:
+		fallthrough
+	default:
+		return fmt.Sprintf("https://%s/%s/%s.git", domain, namespace, repo), nil
+	}
+}
+
+// HasForkTemplate reports whether a fork URL template is configured for the host's clone method
+func (c *Config) HasForkTemplate(domain string) bool {
+	return c.GetHostConfig(domain).URLs[c.GetCloneMethod(domain)].Fork != ""
+}
+
+// GenerateUserRepositoryURL generates a repository URL for a user's fork. Forks live directly in the
+// account's namespace regardless of how deeply the upstream project is nested, as on GitLab.
+func (c *Config) GenerateUserRepositoryURL(domain, repo string) (string, error) {
+	host := c.GetHostConfig(domain)
+	account := c.GetAccount(domain)
+	cloneMethod := c.GetCloneMethod(domain)
+
+	if tmpl := host.URLs[cloneMethod].Fork; tmpl != "" {
+		return renderURL(tmpl, URLTemplateData{
+			Host:    domain,
+			Org:     account,
+			Repo:    repo,
+			Account: account,
+			Port:    host.Port,
+		})
+	}
+
+	if account == "" {
+		// Fallback to HTTP with empty account - this will likely fail but preserves existing behavior
+		return fmt.Sprintf("https://%s//%s.git", domain, repo), nil
+	}
+
+	switch cloneMethod {
+	case CloneMethodSSH:
+		return sshURL(domain, host, account+"/"+repo), nil
+	case CloneMethodHTTP:
+		fallthrough
+	default:
+		return fmt.Sprintf("https://%s/%s/%s.git", domain, account, repo), nil
+	}
+}
+
+// sshURL returns the SSH URL of the repository at path on the host, in scp-style unless a port is configured.
+// The host's SSHHost replaces the domain when it is set.
+func sshURL(domain string, host HostConfig, path string) string {
+	user := host.SSHUser
+	if user == "" {
+		user = "git"
+	}
+	if host.SSHHost != "" {
+		domain = host.SSHHost
+	}
+	if host.Port != "" {
+		return fmt.Sprintf("ssh://%s@%s:%s/%s.git", user, domain, host.Port, path)
+	}
+	return fmt.Sprintf("%s@%s:%s.git", user, domain, path)
+}
+
+func renderURL(text string, data URLTemplateData) (string, error) {
+	return renderTemplate("URL", text, data)
+}
+
+// renderTemplate executes a template from the configuration; kind names it in errors
+func renderTemplate(kind, text string, data any) (string, error) {
+	tmpl, err := template.New(kind).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template %q: %w", kind, text, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template %q: %w", kind, text, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,589 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/progress"
+	"github.com/liamawhite/worktree/pkg/worktree"
+)
+
+const (
+	refreshInterval = 3 * time.Second
+	logPanelLines   = 8
+	maxLogLines     = 500
+)
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).MarginLeft(2)
+	rowStyle      = lipgloss.NewStyle().PaddingLeft(4)
+	selectedStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	branchStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	cleanStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	dirtyStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	lockedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	logStyle      = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	commandStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	promptStyle   = lipgloss.NewStyle().MarginLeft(2)
+	helpBarStyle  = lipgloss.NewStyle().MarginLeft(2)
+)
+
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeCreate
+	modeConfirmRemove
+)
+
+// entry is a worktree row shown in the dashboard
+type entry struct {
+	worktree.WorktreeInfo
+	Locked bool
+}
+
+type refreshMsg struct {
+	entries []entry
+	err     error
+}
+
+type tickMsg time.Time
+
+// actionMsg reports the commands an action ran, in the order they ran
+type actionMsg struct {
+	logs []string
+	err  error
+	// chdir is where the shell goes on exit because the action removed the directory it was in
+	chdir string
+}
+
+// progressMsg is a git progress update from the running action
+type progressMsg progress.Event
+
+type model struct {
+	wm   *worktree.WorktreeManager
+	keys keyMap
+	help help.Model
+
+	entries []entry
+	cursor  int
+	// refreshing counts the refreshes still running; ticks are skipped while one is, so slow git
+	// status calls don't pile up
+	refreshing int
+
+	mode   mode
+	inputs []textinput.Model
+	focus  int
+
+	log      []string
+	busy     string
+	progress progress.Event
+	events   chan progress.Event
+	width    int
+	chdir    string
+}
+
+func newModel(wm *worktree.WorktreeManager) model {
+	name := textinput.New()
+	name.Prompt = "Name: "
+	name.Placeholder = "feature/my-change"
+
+	base := textinput.New()
+	base.Prompt = "Base: "
+
+	return model{
+		wm:     wm,
+		keys:   defaultKeyMap(),
+		help:   help.New(),
+		inputs: []textinput.Model{name, base},
+		events: make(chan progress.Event, 16),
+		width:  80,
+		// Init starts the first refresh
+		refreshing: 1,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.refresh(), tick(), waitForProgress(m.events))
+}
+
+// waitForProgress delivers the next progress update of a running action
+func waitForProgress(events <-chan progress.Event) tea.Cmd {
+	return func() tea.Msg { return progressMsg(<-events) }
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// refresh reloads the worktrees and their status in the background
+func (m model) refresh() tea.Cmd {
+	wm := m.wm
+	return func() tea.Msg {
+		dirs, err := wm.GetWorktreeDirs()
+		if err != nil {
+			return refreshMsg{err: err}
+		}
+
+		locked := map[string]bool{}
+		if worktrees, err := git.ListWorktrees(wm.GitRoot); err == nil {
+			for _, wt := range worktrees {
+				locked[filepath.Clean(wt.Path)] = wt.Locked
+			}
+		}
+
+		var entries []entry
+		for _, info := range wm.GetWorktreeInfo(dirs) {
+			entries = append(entries, entry{WorktreeInfo: info, Locked: locked[filepath.Clean(info.Path)]})
+		}
+		return refreshMsg{entries: entries}
+	}
+}
+
+func (m model) selected() (entry, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.entries) {
+		return entry{}, false
+	}
+	return m.entries[m.cursor], true
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.help.Width = msg.Width
+		return m, nil
+
+	case tickMsg:
+		if m.refreshing > 0 {
+			return m, tick()
+		}
+		m.refreshing++
+		return m, tea.Batch(m.refresh(), tick())
+
+	case refreshMsg:
+		m.refreshing = max(m.refreshing-1, 0)
+		if msg.err != nil {
+			m.appendLog(errorStyle.Render("refresh failed: " + msg.err.Error()))
+			return m, nil
+		}
+		m.entries = msg.entries
+		if m.cursor >= len(m.entries) {
+			m.cursor = max(len(m.entries)-1, 0)
+		}
+		return m, nil
+
+	case progressMsg:
+		if m.busy != "" {
+			m.progress = progress.Event(msg)
+		}
+		return m, waitForProgress(m.events)
+
+	case actionMsg:
+		m.busy = ""
+		m.progress = progress.Event{}
+		for _, l := range msg.logs {
+			m.appendLog(l)
+		}
+		if msg.err != nil {
+			m.appendLog(errorStyle.Render(msg.err.Error()))
+		}
+		if msg.chdir != "" {
+			m.chdir = msg.chdir
+		}
+		m.refreshing++
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case modeCreate:
+			return m.updateCreate(msg)
+		case modeConfirmRemove:
+			return m.updateConfirmRemove(msg)
+		default:
+			return m.updateBrowse(msg)
+		}
+	}
+
+	return m, nil
+}
+
+func (m model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if m.cursor < len(m.entries)-1 {
+			m.cursor++
+		}
+
+	case key.Matches(msg, m.keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+
+	case key.Matches(msg, m.keys.Refresh):
+		m.refreshing++
+		return m, m.refresh()
+
+	case key.Matches(msg, m.keys.Switch):
+		if e, ok := m.selected(); ok {
+			m.chdir = e.Path
+			return m, tea.Quit
+		}
+
+	case key.Matches(msg, m.keys.Create):
+		m.mode = modeCreate
+		m.focus = 0
+		m.inputs[0].SetValue("")
+		m.inputs[1].SetValue(m.wm.BaseBranch())
+		m.inputs[1].Blur()
+		return m, m.inputs[0].Focus()
+
+	case key.Matches(msg, m.keys.Remove):
+		if e, ok := m.selected(); ok {
+			if err := m.wm.CheckRemovable(e.Path); err != nil {
+				m.appendLog(errorStyle.Render(err.Error()))
+				return m, nil
+			}
+			if e.Locked {
+				m.appendLog(errorStyle.Render(fmt.Sprintf("worktree '%s' is locked, unlock it before removing", e.Name)))
+				return m, nil
+			}
+			m.mode = modeConfirmRemove
+		}
+
+	case key.Matches(msg, m.keys.Lock):
+		if e, ok := m.selected(); ok {
+			return m.start("locking", m.lockAction(e))
+		}
+
+	case key.Matches(msg, m.keys.Edit):
+		if e, ok := m.selected(); ok {
+			return m, m.openEditor(e)
+		}
+
+	case key.Matches(msg, m.keys.Fetch):
+		return m.start("fetching", m.fetchAction())
+
+	case key.Matches(msg, m.keys.Sync):
+		if e, ok := m.selected(); ok {
+			return m.start("syncing "+e.Name, m.syncAction(e))
+		}
+	}
+
+	return m, nil
+}
+
+func (m model) updateCreate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeBrowse
+		return m, nil
+
+	case "tab", "shift+tab":
+		return m, m.focusInput(1 - m.focus)
+
+	case "enter":
+		if m.focus == 0 {
+			return m, m.focusInput(1)
+		}
+
+		name := strings.TrimSpace(m.inputs[0].Value())
+		base := strings.TrimSpace(m.inputs[1].Value())
+		if name == "" {
+			return m, m.focusInput(0)
+		}
+		m.mode = modeBrowse
+		return m.start("creating "+name, m.createAction(name, base))
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m *model) focusInput(i int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = i
+	return m.inputs[i].Focus()
+}
+
+func (m model) updateConfirmRemove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	if msg.String() != "y" {
+		return m, nil
+	}
+	e, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	return m.start("removing "+e.Name, m.removeAction(e))
+}
+
+// start runs an action in the background unless another one is still running
+func (m model) start(description string, action tea.Cmd) (tea.Model, tea.Cmd) {
+	if m.busy != "" {
+		m.appendLog(errorStyle.Render("busy " + m.busy + ", try again when it finishes"))
+		return m, nil
+	}
+	m.busy = description
+	return m, action
+}
+
+func (m *model) appendLog(line string) {
+	m.log = append(m.log, strings.Split(strings.TrimRight(line, "\n"), "\n")...)
+	if len(m.log) > maxLogLines {
+		m.log = m.log[len(m.log)-maxLogLines:]
+	}
+}
+
+// run executes a command in dir and returns a log entry containing the command line and its output
+func run(dir, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	return formatLog(name, args, string(output)), err
+}
+
+// runWithProgress is run for git commands that report progress. Updates are sent to events while
+// the log entry keeps only the completed phases.
+func runWithProgress(events chan<- progress.Event, dir, name string, args ...string) (string, error) {
+	var output strings.Builder
+	plain := progress.NewPlain(&output)
+	w := progress.NewWriter(progress.ReporterFunc(func(e progress.Event) {
+		plain.Report(e)
+		if e.Type == progress.EventProgress {
+			select {
+			case events <- e:
+			default:
+				// The dashboard only shows the latest update, so one it hasn't caught up with can be dropped
+			}
+		}
+	}), "")
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+	w.Flush()
+	return formatLog(name, args, output.String()), err
+}
+
+// formatLog renders a command line and its output as a log entry
+func formatLog(name string, args []string, output string) string {
+	logEntry := commandStyle.Render("$ " + strings.Join(append([]string{name}, args...), " "))
+	if out := strings.TrimRight(output, "\n"); out != "" {
+		logEntry += "\n" + out
+	}
+	return logEntry
+}
+
+// sequence runs steps in order, stopping at the first failure
+func sequence(steps ...func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		for _, step := range steps {
+			logEntry, err := step()
+			if logEntry != "" {
+				logs = append(logs, logEntry)
+			}
+			if err != nil {
+				return actionMsg{logs: logs, err: err}
+			}
+		}
+		return actionMsg{logs: logs}
+	}
+}
+
+// commandLog collects the output of worktree operations as a log entry, with each command they run
+// rendered like the commands run by the dashboard itself
+type commandLog struct {
+	strings.Builder
+}
+
+func (l *commandLog) Command(name string, args ...string) {
+	l.WriteString(commandStyle.Render("$ "+strings.Join(append([]string{name}, args...), " ")) + "\n")
+}
+
+func (l *commandLog) String() string {
+	return strings.TrimRight(l.Builder.String(), "\n")
+}
+
+func (m model) createAction(name, base string) tea.Cmd {
+	wm := m.wm
+	return sequence(func() (string, error) {
+		var out commandLog
+		err := wm.AddWorktree(&out, name, base)
+		return out.String(), err
+	})
+}
+
+func (m model) removeAction(e entry) tea.Cmd {
+	wm := m.wm
+	return func() tea.Msg {
+		var out commandLog
+		needsChdir, err := wm.RemoveWorktree(&out, e.Path)
+		msg := actionMsg{logs: []string{out.String()}, err: err}
+		if needsChdir && err == nil {
+			// The shell would be left in a deleted directory, so send it to the repository root on exit
+			msg.chdir = wm.GitRoot
+		}
+		return msg
+	}
+}
+
+func (m model) lockAction(e entry) tea.Cmd {
+	gitRoot := m.wm.GitRoot
+	action := "lock"
+	if e.Locked {
+		action = "unlock"
+	}
+	return sequence(func() (string, error) { return run(gitRoot, "git", "worktree", action, e.Path) })
+}
+
+func (m model) fetchAction() tea.Cmd {
+	gitRoot := m.wm.GitRoot
+	events := m.events
+	args := append([]string{"fetch", "--all", "--prune", "--progress"}, m.wm.CloneOptions().FetchArgs()...)
+	return sequence(func() (string, error) { return runWithProgress(events, gitRoot, "git", args...) })
+}
+
+// syncAction fast-forwards the worktree to its upstream, or to the base branch when it has no upstream
+func (m model) syncAction(e entry) tea.Cmd {
+	base := m.wm.BaseBranch()
+	events := m.events
+	return sequence(func() (string, error) {
+		if _, err := git.RunGitCommandOutputInDir(e.Path, "rev-parse", "--abbrev-ref", "@{upstream}"); err == nil {
+			return runWithProgress(events, e.Path, "git", "pull", "--ff-only", "--progress")
+		}
+		return run(e.Path, "git", "merge", "--ff-only", base)
+	})
+}
+
+// openEditor suspends the dashboard and opens the worktree in $VISUAL or $EDITOR
+func (m model) openEditor(e entry) tea.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], e.Path)...)
+	cmd.Dir = e.Path
+	logEntry := commandStyle.Render("$ " + strings.Join(cmd.Args, " "))
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return actionMsg{logs: []string{logEntry}, err: err}
+	})
+}
+
+func (m model) View() string {
+	var b strings.Builder
+
+	b.WriteString("\n" + headerStyle.Render("Worktrees in "+m.wm.GitRoot) + "\n\n")
+
+	if len(m.entries) == 0 {
+		b.WriteString(rowStyle.Render("No worktrees") + "\n")
+	}
+	for i, e := range m.entries {
+		row := fmt.Sprintf("%-24s %s", e.Name, branchStyle.Render(e.Branch))
+		if e.Dirty {
+			row += " " + dirtyStyle.Render("modified")
+		} else {
+			row += " " + cleanStyle.Render("clean")
+		}
+		if e.Locked {
+			row += " " + lockedStyle.Render("locked")
+		}
+
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> "+row) + "\n")
+		} else {
+			b.WriteString(rowStyle.Render(row) + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	switch m.mode {
+	case modeCreate:
+		b.WriteString(promptStyle.Render("New worktree (tab to switch field, enter to create, esc to cancel)") + "\n")
+		for _, input := range m.inputs {
+			b.WriteString(promptStyle.Render(input.View()) + "\n")
+		}
+		b.WriteString("\n")
+	case modeConfirmRemove:
+		if e, ok := m.selected(); ok {
+			b.WriteString(promptStyle.Render(fmt.Sprintf("Remove worktree %s and delete branch %s? [y/N]", e.Name, e.Branch)) + "\n\n")
+		}
+	}
+
+	if m.busy != "" {
+		busy := m.busy + "..."
+		if m.progress.Phase != "" {
+			busy += " " + m.progress.String()
+		}
+		b.WriteString(promptStyle.Render(busy) + "\n")
+	}
+
+	logLines := m.log
+	if len(logLines) > logPanelLines {
+		logLines = logLines[len(logLines)-logPanelLines:]
+	}
+	logWidth := max(m.width-6, 20)
+	b.WriteString(logStyle.Width(logWidth).Render(
+		lipgloss.NewStyle().MaxWidth(logWidth).Render(strings.Join(logLines, "\n")),
+	) + "\n")
+
+	b.WriteString(helpBarStyle.Render(m.help.View(m.keys)) + "\n")
+	return b.String()
+}
+
+// Run shows the dashboard for the repository managed by wm and returns the
+// directory of the worktree the user switched to, or empty string if they quit
+func Run(wm *worktree.WorktreeManager) (string, error) {
+	p := tea.NewProgram(newModel(wm), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to run dashboard: %w", err)
+	}
+
+	if m, ok := finalModel.(model); ok {
+		return m.chdir, nil
+	}
+
+	return "", nil
+}
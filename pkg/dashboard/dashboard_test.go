@@ -0,0 +1,219 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/progress"
+	"github.com/liamawhite/worktree/pkg/worktree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newManager returns a manager for a new repository in the .bare layout with a main worktree
+func newManager(t *testing.T) *worktree.WorktreeManager {
+	t.Helper()
+	return &worktree.WorktreeManager{GitRoot: gittest.NewBareLayout(t)}
+}
+
+func press(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// execute runs a command and feeds its message back into the model
+func execute(t *testing.T, m tea.Model, cmd tea.Cmd) tea.Model {
+	t.Helper()
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	return m
+}
+
+func TestDashboard_Refresh(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+
+	m = execute(t, m, m.(model).refresh())
+	entries := m.(model).entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, "main", entries[0].Name)
+	assert.Equal(t, "main", entries[0].Branch)
+	assert.False(t, entries[0].Dirty)
+	assert.Contains(t, m.View(), "main")
+}
+
+func TestDashboard_CreateLockAndRemove(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+	m = execute(t, m, m.(model).refresh())
+
+	// Create a worktree through the prompt
+	m, _ = m.Update(press("n"))
+	assert.Equal(t, modeCreate, m.(model).mode)
+	assert.Equal(t, "main", m.(model).inputs[1].Value())
+	for _, r := range "feature" {
+		m, _ = m.Update(press(string(r)))
+	}
+	m, _ = m.Update(press("enter"))
+	m, cmd := m.Update(press("enter"))
+	assert.Equal(t, modeBrowse, m.(model).mode)
+	assert.Equal(t, "creating feature", m.(model).busy)
+
+	m, refresh := m.Update(cmd())
+	assert.Empty(t, m.(model).busy)
+	assert.Contains(t, m.(model).log, "$ git worktree add -b feature "+filepath.Join(wm.GitRoot, "feature")+" main")
+	assert.Contains(t, m.View(), "Preparing worktree (new branch 'feature')")
+	assert.DirExists(t, filepath.Join(wm.GitRoot, "feature"))
+	m = execute(t, m, refresh)
+	require.Len(t, m.(model).entries, 2)
+
+	// Worktrees are listed by name, so the new worktree is highlighted first; lock it
+	assert.Equal(t, "feature", m.(model).entries[m.(model).cursor].Name)
+	m, cmd = m.Update(press("l"))
+	m, refresh = m.Update(cmd())
+	m = execute(t, m, refresh)
+	assert.True(t, m.(model).entries[0].Locked)
+	assert.Contains(t, m.View(), "locked")
+
+	// Locked worktrees cannot be removed until they are unlocked
+	m, _ = m.Update(press("d"))
+	assert.Equal(t, modeBrowse, m.(model).mode)
+	assert.Contains(t, m.View(), "is locked")
+	m, cmd = m.Update(press("l"))
+	m, refresh = m.Update(cmd())
+	m = execute(t, m, refresh)
+	assert.False(t, m.(model).entries[0].Locked)
+
+	// Removing asks for confirmation and deletes the worktree and its branch
+	m, _ = m.Update(press("d"))
+	assert.Equal(t, modeConfirmRemove, m.(model).mode)
+	m, cmd = m.Update(press("y"))
+	m, refresh = m.Update(cmd())
+	m = execute(t, m, refresh)
+	assert.NoDirExists(t, filepath.Join(wm.GitRoot, "feature"))
+	assert.Len(t, m.(model).entries, 1)
+	assert.Contains(t, m.(model).log, "$ git worktree remove "+filepath.Join(wm.GitRoot, "feature")+" --force")
+	assert.Contains(t, m.View(), "Deleted branch feature")
+}
+
+func TestDashboard_TickSkipsWhileRefreshing(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+	refresh := m.(model).refresh()
+
+	// Init's refresh hasn't finished, so the tick only schedules the next one
+	m, _ = m.Update(tickMsg{})
+	assert.Equal(t, 1, m.(model).refreshing)
+
+	m = execute(t, m, refresh)
+	assert.Equal(t, 0, m.(model).refreshing)
+	m, _ = m.Update(tickMsg{})
+	assert.Equal(t, 1, m.(model).refreshing)
+}
+
+func TestDashboard_ManualRefreshCounts(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+	m = execute(t, m, m.(model).refresh())
+	require.Equal(t, 0, m.(model).refreshing)
+
+	// A refresh started by the key is tracked, so a tick while it runs doesn't start another
+	m, cmd := m.Update(press("r"))
+	assert.Equal(t, 1, m.(model).refreshing)
+	m, _ = m.Update(tickMsg{})
+	assert.Equal(t, 1, m.(model).refreshing)
+	m = execute(t, m, cmd)
+	assert.Equal(t, 0, m.(model).refreshing)
+}
+
+func TestDashboard_RemoveCurrentWorktreeChangesDirectory(t *testing.T) {
+	wm := newManager(t)
+	require.NoError(t, wm.AddWorktree(io.Discard, "feature", ""))
+	var m tea.Model = newModel(wm)
+	m = execute(t, m, m.(model).refresh())
+
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Join(wm.GitRoot, "feature")))
+	defer func() { _ = os.Chdir(oldCwd) }()
+
+	require.Equal(t, "feature", m.(model).entries[m.(model).cursor].Name)
+	m, _ = m.Update(press("d"))
+	m, cmd := m.Update(press("y"))
+	m, _ = m.Update(cmd())
+	assert.NoDirExists(t, filepath.Join(wm.GitRoot, "feature"))
+	assert.Equal(t, wm.GitRoot, m.(model).chdir)
+}
+
+func TestDashboard_ProtectedWorktreeIsNotRemoved(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+	m = execute(t, m, m.(model).refresh())
+
+	m, _ = m.Update(press("d"))
+	assert.Equal(t, modeBrowse, m.(model).mode)
+	assert.Contains(t, m.View(), "protected")
+}
+
+func TestDashboard_CancelCreate(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+
+	m, _ = m.Update(press("n"))
+	m, _ = m.Update(press("q"))
+	assert.Equal(t, "q", m.(model).inputs[0].Value(), "keys are typed into the prompt rather than quitting")
+	m, _ = m.Update(press("esc"))
+	assert.Equal(t, modeBrowse, m.(model).mode)
+}
+
+func TestDashboard_SwitchEmitsDirectory(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+	m = execute(t, m, m.(model).refresh())
+
+	m, cmd := m.Update(press("enter"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, filepath.Join(wm.GitRoot, "main"), m.(model).chdir)
+}
+
+func TestDashboard_FetchShowsProgress(t *testing.T) {
+	wm := newManager(t)
+	var m tea.Model = newModel(wm)
+
+	m, cmd := m.Update(press("f"))
+	assert.Equal(t, "fetching", m.(model).busy)
+
+	m, wait := m.Update(progressMsg{Type: progress.EventProgress, Phase: "Receiving objects", Objects: 1, Total: 2, Percent: 50})
+	assert.NotNil(t, wait)
+	assert.Contains(t, m.View(), "fetching... Receiving objects:  50% (1/2)")
+
+	m, _ = m.Update(cmd())
+	assert.Empty(t, m.(model).busy)
+	assert.NotContains(t, m.View(), "Receiving objects")
+	assert.Contains(t, m.(model).log[0], "$ git fetch --all --prune --progress")
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dashboard
+
+import "github.com/charmbracelet/bubbles/key"
+
+type keyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Switch  key.Binding
+	Create  key.Binding
+	Remove  key.Binding
+	Lock    key.Binding
+	Edit    key.Binding
+	Fetch   key.Binding
+	Sync    key.Binding
+	Refresh key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Switch: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "switch"),
+		),
+		Create: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new"),
+		),
+		Remove: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "remove"),
+		),
+		Lock: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "lock/unlock"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "open in editor"),
+		),
+		Fetch: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "fetch"),
+		),
+		Sync: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sync"),
+		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "more"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+	}
+}
+
+// ShortHelp implements help.KeyMap
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Switch, k.Create, k.Remove, k.Fetch, k.Sync, k.Help, k.Quit}
+}
+
+// FullHelp implements help.KeyMap
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Switch},
+		{k.Create, k.Remove, k.Lock, k.Edit},
+		{k.Fetch, k.Sync, k.Refresh},
+		{k.Help, k.Quit},
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Git config keys the clone options are recorded under, so later fetches can respect them
+const (
+	cloneFilterKey       = "wt.clone.filter"
+	cloneDepthKey        = "wt.clone.depth"
+	cloneSingleBranchKey = "wt.clone.singleBranch"
+)
+
+// CloneOptions limit how much of a repository is cloned and where its objects come from
+type CloneOptions struct {
+	// Filter is a partial clone filter such as blob:none. Partial clones and clones with a Reference
+	// are made with the git CLI because go-git doesn't support them.
+	Filter string
+	// Depth truncates history to the given number of commits when positive
+	Depth int
+	// SingleBranch fetches only Branch
+	SingleBranch bool
+	Branch       string
+	// Reference is a repository to borrow objects from through git alternates. It is not recorded.
+	Reference string
+	// Credentials authenticate HTTPS clones. Without them go-git clones that need authentication
+	// fall back to git's credential helpers. They are not recorded.
+	Credentials *Credentials
+	// SSH configures how SSH clones authenticate. It is not recorded, use ConfigureSSHCommand for that.
+	SSH SSHOptions
+}
+
+// IsZero reports whether the options describe a full clone. Reference doesn't change what is cloned.
+func (o CloneOptions) IsZero() bool {
+	return o.Filter == "" && o.Depth == 0 && !o.SingleBranch
+}
+
+// Validate checks the options are consistent
+func (o CloneOptions) Validate() error {
+	if o.Depth < 0 {
+		return fmt.Errorf("depth must be positive, got %d", o.Depth)
+	}
+	if o.SingleBranch && o.Branch == "" {
+		return fmt.Errorf("a single-branch clone needs a branch")
+	}
+	return nil
+}
+
+// FetchArgs returns the arguments fetches need to keep the clone's shape. Partial clone filters
+// don't need any as git records them on the remote.
+func (o CloneOptions) FetchArgs() []string {
+	if o.Depth > 0 {
+		return []string{"--depth=" + strconv.Itoa(o.Depth)}
+	}
+	return nil
+}
+
+// CloneBareWithOptions clones url into a bare repository at path, reporting progress to progress,
+// and records the options in the repository's config
+func CloneBareWithOptions(url, path string, progress io.Writer, opts CloneOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+	if opts.Filter != "" || opts.Reference != "" {
+		err = cloneBareCLI(url, path, progress, opts)
+	} else {
+		err = cloneBareGoGit(url, path, progress, opts)
+	}
+	if err != nil {
+		return err
+	}
+	return SaveCloneOptions(path, opts)
+}
+
+func cloneBareCLI(url, path string, progress io.Writer, opts CloneOptions) error {
+	args := []string{"clone", "--bare", "--progress"}
+	if command := opts.SSH.SSHCommand(); command != "" {
+		args = append([]string{"-c", "core.sshCommand=" + command}, args...)
+	}
+	if opts.Filter != "" {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	if opts.Reference != "" {
+		args = append(args, "--reference-if-able="+opts.Reference)
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(opts.Depth))
+	}
+	if opts.SingleBranch {
+		args = append(args, "--single-branch", "--branch="+opts.Branch)
+	}
+	args = append(args, "--", url, path)
+	return runGitWithCredentials(progress, ".", opts.Credentials, args...)
+}
+
+// SaveCloneOptions records opts in the config of the repository at gitDir
+func SaveCloneOptions(gitDir string, opts CloneOptions) error {
+	values := map[string]string{}
+	if opts.Filter != "" {
+		values[cloneFilterKey] = opts.Filter
+	}
+	if opts.Depth > 0 {
+		values[cloneDepthKey] = strconv.Itoa(opts.Depth)
+	}
+	if opts.SingleBranch {
+		values[cloneSingleBranchKey] = "true"
+	}
+	for key, value := range values {
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", key, value); err != nil {
+			return fmt.Errorf("failed to record %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// LoadCloneOptions returns the clone options recorded in the repository at gitDir. Repositories
+// that weren't cloned by wt are treated as full clones.
+func LoadCloneOptions(gitDir string) CloneOptions {
+	var opts CloneOptions
+	opts.Filter, _ = RunGitCommandOutput("--git-dir="+gitDir, "config", cloneFilterKey)
+	if depth, err := RunGitCommandOutput("--git-dir="+gitDir, "config", cloneDepthKey); err == nil {
+		opts.Depth, _ = strconv.Atoi(depth)
+	}
+	if single, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--type=bool", cloneSingleBranchKey); err == nil {
+		opts.SingleBranch = single == "true"
+	}
+	return opts
+}
+
+// FetchBranch fetches branch from remote into the local branch of the same name, keeping the clone's shape
+func FetchBranch(out io.Writer, gitDir, remote, branch string, opts CloneOptions) error {
+	args := append([]string{"--git-dir=" + gitDir, "fetch", "--progress"}, opts.FetchArgs()...)
+	args = append(args, remote, "refs/heads/"+branch+":refs/heads/"+branch)
+	return RunGitCommandInDirTo(out, ".", args...)
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistory creates a repository with three commits on main and a second branch
+func newHistory(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	commit := []string{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "commit"}
+	for _, args := range [][]string{
+		{"init", "--initial-branch=main"},
+		{"config", "uploadpack.allowFilter", "true"},
+		commit, commit, commit,
+		{"branch", "other"},
+	} {
+		require.NoError(t, RunGitCommandInDirTo(io.Discard, src, args...))
+	}
+	return "file://" + filepath.ToSlash(src)
+}
+
+func revCount(t *testing.T, gitDir, ref string) string {
+	t.Helper()
+	count, err := RunGitCommandOutput("--git-dir="+gitDir, "rev-list", "--count", ref)
+	require.NoError(t, err)
+	return count
+}
+
+func TestCloneBareWithOptions(t *testing.T) {
+	url := newHistory(t)
+
+	t.Run("full", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), ".bare")
+		require.NoError(t, CloneBareWithOptions(url, dst, io.Discard, CloneOptions{}))
+		assert.Equal(t, "3", revCount(t, dst, "main"))
+		assert.True(t, LoadCloneOptions(dst).IsZero())
+	})
+
+	t.Run("shallow single branch", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), ".bare")
+		opts := CloneOptions{Depth: 1, SingleBranch: true, Branch: "main"}
+		require.NoError(t, CloneBareWithOptions(url, dst, io.Discard, opts))
+		assert.Equal(t, "1", revCount(t, dst, "main"))
+
+		_, err := RunGitCommandOutput("--git-dir="+dst, "rev-parse", "--verify", "--quiet", "refs/heads/other")
+		assert.Error(t, err, "other branches should not be cloned")
+		assert.Equal(t, CloneOptions{Depth: 1, SingleBranch: true}, LoadCloneOptions(dst))
+
+		require.NoError(t, FetchBranch(io.Discard, dst, "origin", "other", LoadCloneOptions(dst)))
+		assert.Equal(t, "1", revCount(t, dst, "other"))
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), ".bare")
+		require.NoError(t, CloneBareWithOptions(url, dst, io.Discard, CloneOptions{Filter: "blob:none"}))
+		assert.Equal(t, "3", revCount(t, dst, "main"))
+
+		promisor, err := RunGitCommandOutput("--git-dir="+dst, "config", "remote.origin.promisor")
+		require.NoError(t, err)
+		assert.Equal(t, "true", promisor)
+		assert.Equal(t, CloneOptions{Filter: "blob:none"}, LoadCloneOptions(dst))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), ".bare")
+		assert.ErrorContains(t, CloneBareWithOptions(url, dst, io.Discard, CloneOptions{Depth: -1}), "depth must be positive")
+		assert.ErrorContains(t, CloneBareWithOptions(url, dst, io.Discard, CloneOptions{SingleBranch: true}), "needs a branch")
+	})
+}
+
+func TestCloneOptions_FetchArgs(t *testing.T) {
+	assert.Nil(t, CloneOptions{Filter: "blob:none"}.FetchArgs())
+	assert.Equal(t, []string{"--depth=5"}, CloneOptions{Depth: 5}.FetchArgs())
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Credentials authenticate HTTPS requests, usually with an access token as the password
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// envHelper is a credential helper that answers with the credentials passed in the environment,
+// so they never appear on a command line
+const envHelper = `!f() { test "$1" = get && printf 'username=%s\npassword=%s\n' "$WT_GIT_USERNAME" "$WT_GIT_PASSWORD"; }; f`
+
+// FillCredentials asks git's credential helpers for credentials for url using `git credential fill`.
+// It never prompts, and returns nil when url isn't HTTP(S) or no helper has credentials.
+func FillCredentials(rawURL string) *Credentials {
+	u, err := url.Parse(rawURL)
+	if err != nil || !isHTTPURL(rawURL) {
+		return nil
+	}
+
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")))
+	// Without a terminal prompt or askpass program git fails instead of asking the user
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	creds := parseCredentials(strings.NewReader(string(output)))
+	if creds.Password == "" {
+		return nil
+	}
+	return &creds
+}
+
+// parseCredentials reads the username and password from git credential helper protocol output
+func parseCredentials(r io.Reader) Credentials {
+	var creds Credentials
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "username":
+			creds.Username = value
+		case "password":
+			creds.Password = value
+		}
+	}
+	return creds
+}
+
+// ConfigureCredentialHelper makes git in the repository at gitDir ask helper for credentials for
+// the host of url, after any helpers the user configured
+func ConfigureCredentialHelper(gitDir, rawURL, helper string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	key := fmt.Sprintf("credential.%s://%s.helper", u.Scheme, u.Host)
+	if current, _ := RunGitCommandOutput("--git-dir="+gitDir, "config", "--get-all", key); strings.Contains(current, helper) {
+		return nil
+	}
+	_, err = RunGitCommandOutput("--git-dir="+gitDir, "config", "--add", key, helper)
+	return err
+}
+
+// runGitWithCredentials runs a git command in dir like RunGitCommandInDirTo, authenticating
+// HTTPS requests with creds when they are not nil
+func runGitWithCredentials(out io.Writer, dir string, creds *Credentials, args ...string) error {
+	if creds == nil {
+		return RunGitCommandInDirTo(out, dir, args...)
+	}
+	// An empty helper first clears the user's helpers so theirs can't answer with other credentials
+	cmd := exec.Command("git", append([]string{"-c", "credential.helper=", "-c", "credential.helper=" + envHelper}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "WT_GIT_USERNAME="+creds.Username, "WT_GIT_PASSWORD="+creds.Password)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// isHTTPURL reports whether url is an http:// or https:// URL
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
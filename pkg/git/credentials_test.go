@@ -0,0 +1,121 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useHelper configures a git credential helper that answers with username and password for every host
+func useHelper(t *testing.T, username, password string) {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "credential.helper")
+	t.Setenv("GIT_CONFIG_VALUE_0", "!f() { echo username="+username+"; echo password="+password+"; }; f")
+}
+
+// authServer asks for basic auth and records the credentials it is sent. It never serves a repository.
+func authServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		seen = append(seen, username+":"+password)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestFillCredentials(t *testing.T) {
+	useHelper(t, "user", "secret")
+
+	assert.Equal(t, &Credentials{Username: "user", Password: "secret"}, FillCredentials("https://example.com/org/repo.git"))
+	assert.Nil(t, FillCredentials("git@example.com:org/repo.git"))
+}
+
+func TestFillCredentials_NoHelper(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	assert.Nil(t, FillCredentials("https://example.com/org/repo.git"))
+}
+
+func TestCloneBareWithOptions_Credentials(t *testing.T) {
+	// The user's helpers are ignored when credentials are given
+	useHelper(t, "helper", "wrong")
+	creds := &Credentials{Username: "x-access-token", Password: "token"}
+
+	for name, opts := range map[string]CloneOptions{
+		"go-git":  {Credentials: creds},
+		"git CLI": {Credentials: creds, Filter: "blob:none"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv, seen := authServer(t)
+			err := CloneBareWithOptions(srv.URL+"/org/repo.git", filepath.Join(t.TempDir(), ".bare"), io.Discard, opts)
+			require.Error(t, err)
+			require.NotEmpty(t, seen())
+			assert.Equal(t, "x-access-token:token", seen()[0])
+		})
+	}
+}
+
+func TestCloneBareWithOptions_FallsBackToCredentialHelpers(t *testing.T) {
+	useHelper(t, "user", "secret")
+	srv, seen := authServer(t)
+
+	err := CloneBareWithOptions(srv.URL+"/org/repo.git", filepath.Join(t.TempDir(), ".bare"), io.Discard, CloneOptions{})
+	require.Error(t, err)
+	assert.Equal(t, []string{"user:secret"}, seen())
+}
+
+func TestConfigureCredentialHelper(t *testing.T) {
+	gitDir := filepath.Join(t.TempDir(), ".bare")
+	require.NoError(t, RunGitCommandInDirTo(io.Discard, ".", "init", "--bare", gitDir))
+
+	for range 2 {
+		require.NoError(t, ConfigureCredentialHelper(gitDir, "https://example.com:8443/org/repo.git", "!wt credential"))
+	}
+
+	helpers, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--get-all", "credential.https://example.com:8443.helper")
+	require.NoError(t, err)
+	assert.Equal(t, "!wt credential", helpers)
+}
+
+func TestParseCredentials(t *testing.T) {
+	creds := parseCredentials(strings.NewReader("protocol=https\nhost=example.com\nusername=user\npassword=a=b\n"))
+	assert.Equal(t, Credentials{Username: "user", Password: "a=b"}, creds)
+}
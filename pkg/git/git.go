@@ -15,8 +15,9 @@
 package git
 
 import (
+	"errors"
 	"fmt"
-	"net"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -26,10 +27,13 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
-	"golang.org/x/crypto/ssh/agent"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+// FindGitRoot returns the root of the repository containing the current directory. For the bare
+// layout created by setup this is the directory holding .bare; for a standard clone it is the
+// main checkout, even when called from one of its linked worktrees. Submodules and clones made with
+// --separate-git-dir resolve to their checkout.
 func FindGitRoot() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -41,28 +45,44 @@ func FindGitRoot() (string, error) {
 		return cwd, nil
 	}
 
-	// Use go-git to find repository root
-	_, err = git.PlainOpenWithOptions(cwd, &git.PlainOpenOptions{
-		DetectDotGit: true,
-	})
+	commonDir, err := GitCommonDir(cwd)
 	if err != nil {
 		return "", fmt.Errorf("not in a git repository: %w", err)
 	}
 
-	// Walk up directories to find the root
-	dir := cwd
-	for {
-		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
-			return filepath.Dir(dir), nil
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
-		}
-		dir = parent
+	// The common directory is <root>/.bare for the bare layout and <root>/.git for a standard clone
+	if name := filepath.Base(commonDir); name == ".git" || name == ".bare" {
+		return filepath.Dir(commonDir), nil
 	}
+	return mainWorktree(cwd, commonDir)
+}
 
-	return "", fmt.Errorf("git repository root not found")
+// mainWorktree returns the main checkout of a repository whose git directory lives elsewhere,
+// such as a submodule (.git/modules/<name>) or a clone made with --separate-git-dir
+func mainWorktree(dir, commonDir string) (string, error) {
+	// Submodules record their checkout as core.worktree
+	if _, err := RunGitCommandOutput("--git-dir="+commonDir, "config", "core.worktree"); err == nil {
+		return RunGitCommandOutput("--git-dir="+commonDir, "rev-parse", "--show-toplevel")
+	}
+
+	// Otherwise the main checkout isn't recorded anywhere, so it's only known from inside it
+	gitDir, err := RunGitCommandOutputInDir(dir, "rev-parse", "--path-format=absolute", "--git-dir")
+	if err != nil {
+		return "", err
+	}
+	if filepath.Clean(gitDir) != commonDir {
+		return "", fmt.Errorf("the git directory %s is outside the repository, run wt from its main checkout", commonDir)
+	}
+	return RunGitCommandOutputInDir(dir, "rev-parse", "--show-toplevel")
+}
+
+// GitCommonDir returns the absolute path of the git directory shared by all worktrees of the repository at dir
+func GitCommonDir(dir string) (string, error) {
+	commonDir, err := RunGitCommandOutputInDir(dir, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(commonDir), nil
 }
 
 func RunGitCommand(args ...string) error {
@@ -80,6 +100,15 @@ func RunGitCommandInDir(dir string, args ...string) error {
 	return cmd.Run()
 }
 
+// RunGitCommandInDirTo runs a git command in dir, writing its output and errors to out
+func RunGitCommandInDirTo(out io.Writer, dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
 func RunGitCommandOutput(args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	output, err := cmd.Output()
@@ -89,6 +118,19 @@ func RunGitCommandOutput(args ...string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// RunGitCommandOutputInDir runs a git command in dir and returns its output
+// with trailing newlines removed. Leading whitespace is preserved because it
+// is significant in formats such as `git status --short`.
+func RunGitCommandOutputInDir(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
 func RunCommand(name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Stdout = os.Stdout
@@ -104,96 +146,146 @@ func RunCommandInDir(dir, name string, args ...string) error {
 	return cmd.Run()
 }
 
+// RunCommandInDirTo runs a command in dir, writing its output and errors to out
+func RunCommandInDirTo(out io.Writer, dir, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
 // CloneBare clones a repository as a bare repository using go-git
 func CloneBare(url, path string) error {
+	return CloneBareWithProgress(url, path, os.Stdout)
+}
+
+// CloneBareWithProgress clones url into a bare repository at path, reporting progress to progress
+func CloneBareWithProgress(url, path string, progress io.Writer) error {
+	return CloneBareWithOptions(url, path, progress, CloneOptions{})
+}
+
+func cloneBareGoGit(url, path string, progress io.Writer, opts CloneOptions) error {
 	cloneOptions := &git.CloneOptions{
-		URL:      url,
-		Progress: os.Stdout,
+		URL:          url,
+		Progress:     progress,
+		Depth:        opts.Depth,
+		SingleBranch: opts.SingleBranch,
+	}
+	if opts.SingleBranch {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(opts.Branch)
 	}
 
-	// If this is an SSH URL, configure SSH authentication
-	if strings.HasPrefix(url, "git@") {
-		auth, err := getSSHAuth()
+	// If this is an SSH URL, configure SSH authentication. Local paths and file:// URLs need none
+	var hostKeyErr error
+	if isSSHURL(url) {
+		auth, err := getSSHAuth(url, opts.SSH)
 		if err != nil {
 			return fmt.Errorf("failed to configure SSH authentication: %w", err)
 		}
+		opts.SSH.checkHostKeys(auth, url, &hostKeyErr)
 		cloneOptions.Auth = auth
 	}
+	if isHTTPURL(url) && opts.Credentials != nil {
+		cloneOptions.Auth = &githttp.BasicAuth{Username: opts.Credentials.Username, Password: opts.Credentials.Password}
+	}
 
 	_, err := git.PlainClone(path, true, cloneOptions)
-	if err != nil && strings.Contains(err.Error(), "knownhosts: key mismatch") {
-		return fmt.Errorf("SSH host key verification failed. Run: ssh-keyscan %s >> ~/.ssh/known_hosts", extractHostFromURL(url))
+	if isHTTPURL(url) && opts.Credentials == nil && isAuthError(err) {
+		// Public repositories need no credentials, so git's credential helpers are only asked once the server wants some
+		if creds := FillCredentials(url); creds != nil {
+			_ = os.RemoveAll(path)
+			cloneOptions.Auth = &githttp.BasicAuth{Username: creds.Username, Password: creds.Password}
+			_, err = git.PlainClone(path, true, cloneOptions)
+		}
+	}
+	if isAuthError(err) {
+		return fmt.Errorf("authentication failed for %s, configure an access token with 'wt config set-token' or a git credential helper: %w", url, err)
+	}
+	if err != nil && hostKeyErr != nil {
+		return hostKeyErr
 	}
 	return err
 }
 
-// getSSHAuth configures SSH authentication using the SSH agent or default key locations
-func getSSHAuth() (transport.AuthMethod, error) {
-	// Try to connect to SSH agent using SSH_AUTH_SOCK
-	if authSock := os.Getenv("SSH_AUTH_SOCK"); authSock != "" {
-		conn, err := net.Dial("unix", authSock)
-		if err == nil {
-			agentClient := agent.NewClient(conn)
-			keys, err := agentClient.List()
-			if err == nil && len(keys) > 0 {
-				auth, err := gitssh.NewSSHAgentAuth("git")
-				if err == nil {
-					return auth, nil
-				}
-			}
-			_ = conn.Close()
-		}
-	}
+// isAuthError reports whether err is a server asking for credentials or rejecting them
+func isAuthError(err error) bool {
+	return errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed)
+}
 
-	// Fallback to go-git's SSH agent implementation
-	auth, err := gitssh.NewSSHAgentAuth("git")
-	if err == nil {
-		return auth, nil
-	}
+// CurrentBranch returns the name of the branch checked out in the worktree at dir
+func CurrentBranch(dir string) (string, error) {
+	return RunGitCommandOutputInDir(dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
 
-	// Fallback to default SSH key locations
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
-	}
+// StatusShort returns the `git status --short` output for the worktree at dir
+func StatusShort(dir string) (string, error) {
+	return RunGitCommandOutputInDir(dir, "status", "--short")
+}
 
-	// Try common SSH key locations
-	keyPaths := []string{
-		filepath.Join(homeDir, ".ssh", "id_rsa"),
-		filepath.Join(homeDir, ".ssh", "id_ed25519"),
-		filepath.Join(homeDir, ".ssh", "id_ecdsa"),
-	}
+// RecentCommits returns the last n commits of the worktree at dir in oneline format
+func RecentCommits(dir string, n int) (string, error) {
+	return RunGitCommandOutputInDir(dir, "log", "--oneline", "--no-decorate", fmt.Sprintf("-n%d", n))
+}
 
-	for _, keyPath := range keyPaths {
-		if _, err := os.Stat(keyPath); err == nil {
-			auth, err := gitssh.NewPublicKeysFromFile("git", keyPath, "")
-			if err == nil {
-				return auth, nil
-			}
-		}
-	}
+// DiffStat returns the diffstat of the worktree at dir against the merge base with base
+func DiffStat(dir, base string) (string, error) {
+	return RunGitCommandOutputInDir(dir, "diff", "--stat", base+"...HEAD")
+}
 
-	return nil, fmt.Errorf("no SSH authentication method available (tried SSH agent and common key locations)")
+// Worktree is an entry reported by `git worktree list`
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string
+	Bare     bool
+	Detached bool
+	Locked   bool
 }
 
-// extractHostFromURL extracts the hostname from a git URL
-func extractHostFromURL(url string) string {
-	if strings.HasPrefix(url, "git@") {
-		// SSH format: git@hostname:org/repo.git
-		parts := strings.Split(url, ":")
-		if len(parts) > 0 {
-			return strings.TrimPrefix(parts[0], "git@")
-		}
-	} else if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
-		// HTTP/HTTPS format: https://hostname/org/repo.git
-		url = strings.TrimPrefix(url, "https://")
-		url = strings.TrimPrefix(url, "http://")
-		parts := strings.Split(url, "/")
-		if len(parts) > 0 {
-			return parts[0]
+// ListWorktrees returns the worktrees git has recorded for the repository containing dir
+func ListWorktrees(dir string) ([]Worktree, error) {
+	output, err := RunGitCommandOutputInDir(dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktreeList(output), nil
+}
+
+// parseWorktreeList parses the porcelain output of `git worktree list`, where each
+// worktree is a block of attribute lines separated by a blank line
+func parseWorktreeList(output string) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		case "detached":
+			if current != nil {
+				current.Detached = true
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+			}
 		}
 	}
-	return "github.com"
+	return worktrees
 }
 
 // OpenRepository opens a git repository using go-git
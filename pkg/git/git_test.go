@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/liamawhite/worktree/internal/gittest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -45,6 +46,50 @@ func TestFindGitRoot(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "finds the checkout of a clone with a separate git directory",
+			setup: func(t *testing.T) (string, func()) {
+				upstream := gittest.InitRepo(t)
+				tmpDir := t.TempDir()
+				clone := filepath.Join(tmpDir, "clone")
+				gittest.Run(t, tmpDir, "clone", "--separate-git-dir="+filepath.Join(tmpDir, "gitdir"), upstream, clone)
+
+				oldCwd, _ := os.Getwd()
+				_ = os.Chdir(clone)
+
+				return clone, func() { _ = os.Chdir(oldCwd) }
+			},
+		},
+		{
+			name: "fails in a linked worktree of a clone with a separate git directory",
+			setup: func(t *testing.T) (string, func()) {
+				upstream := gittest.InitRepo(t)
+				tmpDir := t.TempDir()
+				clone := filepath.Join(tmpDir, "clone")
+				gittest.Run(t, tmpDir, "clone", "--separate-git-dir="+filepath.Join(tmpDir, "gitdir"), upstream, clone)
+				gittest.Run(t, clone, "worktree", "add", "-b", "feature", filepath.Join(tmpDir, "feature"))
+
+				oldCwd, _ := os.Getwd()
+				_ = os.Chdir(filepath.Join(tmpDir, "feature"))
+
+				return "", func() { _ = os.Chdir(oldCwd) }
+			},
+			wantErr: true,
+		},
+		{
+			name: "finds the checkout of a submodule",
+			setup: func(t *testing.T) (string, func()) {
+				upstream := gittest.InitRepo(t)
+				super := gittest.InitRepo(t)
+				gittest.Run(t, super, "-c", "protocol.file.allow=always", "submodule", "add", upstream, "sub")
+				sub := filepath.Join(super, "sub")
+
+				oldCwd, _ := os.Getwd()
+				_ = os.Chdir(sub)
+
+				return sub, func() { _ = os.Chdir(oldCwd) }
+			},
+		},
 		{
 			name: "fails outside git repo",
 			setup: func(t *testing.T) (string, func()) {
@@ -107,3 +152,60 @@ func TestRunGitCommandOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /repo/.bare
+bare
+
+worktree /repo/main
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /repo/feature
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/login
+locked reason here
+
+worktree /repo/detached
+HEAD 3333333333333333333333333333333333333333
+detached
+`
+
+	got := parseWorktreeList(output)
+	require.Len(t, got, 4)
+	assert.Equal(t, Worktree{Path: "/repo/.bare", Bare: true}, got[0])
+	assert.Equal(t, "main", got[1].Branch)
+	assert.Equal(t, "1111111111111111111111111111111111111111", got[1].Head)
+	assert.Equal(t, "feature/login", got[2].Branch)
+	assert.True(t, got[2].Locked)
+	assert.True(t, got[3].Detached)
+	assert.Empty(t, got[3].Branch)
+}
+
+func TestCloneBare_Local(t *testing.T) {
+	src := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--initial-branch=main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial commit"},
+	} {
+		require.NoError(t, RunGitCommandInDir(src, args...))
+	}
+
+	for name, url := range map[string]string{
+		"path":     src,
+		"file URL": "file://" + filepath.ToSlash(src),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), ".bare")
+			require.NoError(t, CloneBare(url, dst))
+
+			origin, err := RunGitCommandOutputInDir(dst, "remote", "get-url", "origin")
+			require.NoError(t, err)
+			assert.Equal(t, url, origin)
+
+			head, err := RunGitCommandOutputInDir(dst, "rev-parse", "--verify", "refs/heads/main")
+			require.NoError(t, err)
+			assert.NotEmpty(t, head)
+		})
+	}
+}
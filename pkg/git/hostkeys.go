@@ -0,0 +1,243 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/skeema/knownhosts"
+	"golang.org/x/crypto/ssh"
+)
+
+// HostKey is the key an SSH host presented
+type HostKey struct {
+	// Host is the host and, when it isn't 22, the port, as recorded in known_hosts
+	Host string
+	// Type is the key algorithm, e.g. ssh-ed25519
+	Type string
+	// Fingerprint is the SHA256 fingerprint of the key, e.g. SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU
+	Fingerprint string
+
+	key ssh.PublicKey
+}
+
+func newHostKey(host string, key ssh.PublicKey) HostKey {
+	return HostKey{Host: knownhosts.Normalize(host), Type: key.Type(), Fingerprint: ssh.FingerprintSHA256(key), key: key}
+}
+
+// UnknownHostError is returned when a host's key isn't in known_hosts and wasn't trusted
+type UnknownHostError struct {
+	HostKey
+}
+
+func (e *UnknownHostError) Error() string {
+	return fmt.Sprintf("the SSH host %s is not known (%s key %s). Verify the fingerprint with the host's administrator and run 'wt ssh trust %s'", e.Host, e.Type, e.Fingerprint, e.Host)
+}
+
+// HostKeyChangedError is returned when a host presents a key other than the one in known_hosts or
+// the one pinned in the configuration
+type HostKeyChangedError struct {
+	HostKey
+	// Pinned is set when the key didn't match the pinned fingerprint rather than known_hosts
+	Pinned string
+}
+
+func (e *HostKeyChangedError) Error() string {
+	if e.Pinned != "" {
+		return fmt.Sprintf("the SSH host key of %s (%s) does not match the pinned fingerprint %s. Someone could be intercepting the connection; don't trust the key until the host's administrator confirms it changed", e.Host, e.Fingerprint, e.Pinned)
+	}
+	return fmt.Sprintf("the SSH host key of %s has changed to %s %s. Someone could be intercepting the connection. If the host's administrator confirms the change, remove the old key with 'ssh-keygen -R %s' and run 'wt ssh trust %s'", e.Host, e.Type, e.Fingerprint, e.Host, e.Host)
+}
+
+// knownHostsFiles returns the known_hosts files SSH connections are checked against: those listed in
+// SSH_KNOWN_HOSTS, or the user's and the system's. The first one is where trusted keys are added.
+func knownHostsFiles() ([]string, error) {
+	if files := filepath.SplitList(os.Getenv("SSH_KNOWN_HOSTS")); len(files) > 0 {
+		return files, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return []string{filepath.Join(home, ".ssh", "known_hosts"), "/etc/ssh/ssh_known_hosts"}, nil
+}
+
+// knownHosts loads the known_hosts files that exist
+func knownHosts() (*knownhosts.HostKeyDB, error) {
+	files, err := knownHostsFiles()
+	if err != nil {
+		return nil, err
+	}
+	var existing []string
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			existing = append(existing, file)
+		}
+	}
+	return knownhosts.NewDB(existing...)
+}
+
+// CheckHostKey checks key against known_hosts, returning an *UnknownHostError or a *HostKeyChangedError
+// when it isn't trusted
+func CheckHostKey(key HostKey) error {
+	return checkKnownHost(key.Host, key.key)
+}
+
+// checkKnownHost checks the key host presented against known_hosts. host is a host name with an optional port.
+func checkKnownHost(host string, key ssh.PublicKey) error {
+	db, err := knownHosts()
+	if err != nil {
+		return err
+	}
+	err = db.HostKeyCallback()(hostWithPort(host), &net.TCPAddr{}, key)
+	switch {
+	case knownhosts.IsHostUnknown(err):
+		return &UnknownHostError{HostKey: newHostKey(host, key)}
+	case knownhosts.IsHostKeyChanged(err):
+		return &HostKeyChangedError{HostKey: newHostKey(host, key)}
+	}
+	return err
+}
+
+// TrustHostKey adds the host's key to the user's known_hosts file
+func TrustHostKey(key HostKey) error {
+	files, err := knownHostsFiles()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(files[0]), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(files[0], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", files[0], err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := fmt.Fprintln(f, knownhosts.Line([]string{key.Host}, key.key)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", files[0], err)
+	}
+	return nil
+}
+
+// errHostKeyFetched stops the handshake once the host key has been seen
+var errHostKeyFetched = errors.New("host key fetched")
+
+// FetchHostKey connects to host, a host name or ssh_config Host alias with an optional port, and
+// returns the key it presents. The host name and port of an alias are resolved from ssh_config.
+// When the host is already in known_hosts the key of the same type is asked for, so it can be
+// compared with CheckHostKey.
+func FetchHostKey(host string) (HostKey, error) {
+	addr := ResolveSSHHost(host)
+	db, err := knownHosts()
+	if err != nil {
+		return HostKey{}, err
+	}
+
+	var fetched HostKey
+	config := &ssh.ClientConfig{
+		User: "git",
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			fetched = newHostKey(addr, key)
+			return errHostKeyFetched
+		},
+		HostKeyAlgorithms: db.HostKeyAlgorithms(addr),
+		Timeout:           10 * time.Second,
+	}
+	conn, err := net.DialTimeout("tcp", addr, config.Timeout)
+	if err != nil {
+		return HostKey{}, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, _, _, err := ssh.NewClientConn(conn, addr, config); !errors.Is(err, errHostKeyFetched) {
+		return HostKey{}, fmt.Errorf("failed to fetch the host key of %s: %w", addr, err)
+	}
+	return fetched, nil
+}
+
+// ResolveSSHHost returns the host:port SSH connects to for host, a host name or ssh_config Host alias
+// with an optional port. A port given with host wins over the alias's.
+func ResolveSSHHost(host string) string {
+	alias, port, err := net.SplitHostPort(host)
+	if err != nil {
+		alias = host
+	}
+	name := alias
+	if hostname := sshConfig.Get(alias, "HostName"); hostname != "" {
+		name = hostname
+	}
+	if port == "" {
+		port = sshConfig.Get(alias, "Port")
+	}
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(name, port)
+}
+
+// hostWithPort adds the default SSH port to host when it has none
+func hostWithPort(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), "22")
+}
+
+// checkHostKeys makes auth verify host keys with VerifyHostKey. Why a key was rejected is stored in
+// rejected, as go-git doesn't keep the error.
+func (o SSHOptions) checkHostKeys(auth transport.AuthMethod, url string, rejected *error) {
+	var helper *gitssh.HostKeyCallbackHelper
+	switch a := auth.(type) {
+	case *gitssh.PublicKeys:
+		helper = &a.HostKeyCallbackHelper
+	case *gitssh.PublicKeysCallback:
+		helper = &a.HostKeyCallbackHelper
+	default:
+		return
+	}
+	helper.HostKeyCallback = func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		*rejected = o.VerifyHostKey(newHostKey(hostname, key))
+		return *rejected
+	}
+	// Asking for a key type known_hosts has stops a host with several keys looking like it changed
+	if db, err := knownHosts(); err == nil {
+		helper.HostKeyAlgorithms = db.HostKeyAlgorithms(ResolveSSHHost(sshHost(url)))
+	}
+}
+
+// VerifyHostKey checks key against known_hosts. An unknown key is added to known_hosts when it
+// matches the pinned fingerprint or, without one, when ConfirmHostKey accepts it.
+func (o SSHOptions) VerifyHostKey(key HostKey) error {
+	err := CheckHostKey(key)
+	var unknown *UnknownHostError
+	if !errors.As(err, &unknown) {
+		return err
+	}
+	if o.HostKeyFingerprint != "" {
+		if key.Fingerprint != o.HostKeyFingerprint {
+			return &HostKeyChangedError{HostKey: key, Pinned: o.HostKeyFingerprint}
+		}
+	} else if o.ConfirmHostKey == nil || !o.ConfirmHostKey(key) {
+		return err
+	}
+	return TrustHostKey(key)
+}
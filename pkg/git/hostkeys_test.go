@@ -0,0 +1,248 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshServer serves the git repositories under root over SSH to any client key, returning its
+// address and host key
+func sshServer(t *testing.T, root string) (string, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config, root)
+		}
+	}()
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func serveSSH(conn net.Conn, config *ssh.ServerConfig, root string) {
+	defer func() { _ = conn.Close() }()
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer func() { _ = channel.Close() }()
+			for req := range requests {
+				if req.Type != "exec" || len(req.Payload) < 4 {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				// The payload is the command as an SSH string, e.g. git-upload-pack '/org/repo.git'
+				cmd := exec.Command("sh", "-c", string(req.Payload[4:]))
+				cmd.Dir = root
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = channel, channel, channel.Stderr()
+				status := uint32(0)
+				if err := cmd.Run(); err != nil {
+					status = 1
+				}
+				_, _ = channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+				return
+			}
+		}()
+	}
+}
+
+// useKnownHosts keeps known_hosts in a file of the test for the rest of the test
+func useKnownHosts(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	t.Setenv("SSH_KNOWN_HOSTS", file)
+	return file
+}
+
+func TestFetchHostKey(t *testing.T) {
+	useKnownHosts(t)
+	useSSHConfig(t, "")
+	addr, key := sshServer(t, t.TempDir())
+
+	hostKey, err := FetchHostKey(addr)
+	require.NoError(t, err)
+	assert.Equal(t, ssh.FingerprintSHA256(key), hostKey.Fingerprint)
+	assert.Equal(t, "ssh-ed25519", hostKey.Type)
+
+	_, port, _ := net.SplitHostPort(addr)
+	assert.Equal(t, "[127.0.0.1]:"+port, hostKey.Host)
+}
+
+func TestResolveSSHHost(t *testing.T) {
+	useSSHConfig(t, `
+Host work-github
+  HostName github.com
+
+Host bitbucket
+  HostName bitbucket.company.com
+  Port 7999
+`)
+	assert.Equal(t, "github.com:22", ResolveSSHHost("work-github"))
+	assert.Equal(t, "bitbucket.company.com:7999", ResolveSSHHost("bitbucket"))
+	assert.Equal(t, "bitbucket.company.com:2222", ResolveSSHHost("bitbucket:2222"))
+	assert.Equal(t, "gitlab.com:22", ResolveSSHHost("gitlab.com"))
+}
+
+func TestCheckHostKey(t *testing.T) {
+	knownHostsFile := useKnownHosts(t)
+	signer := func() ssh.PublicKey {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		s, err := ssh.NewSignerFromKey(priv)
+		require.NoError(t, err)
+		return s.PublicKey()
+	}
+	key, other := signer(), signer()
+
+	var unknown *UnknownHostError
+	require.ErrorAs(t, checkKnownHost("git.company.com:7999", key), &unknown)
+	assert.Equal(t, "[git.company.com]:7999", unknown.Host)
+	assert.Equal(t, ssh.FingerprintSHA256(key), unknown.Fingerprint)
+	assert.ErrorContains(t, unknown, "wt ssh trust [git.company.com]:7999")
+
+	require.NoError(t, TrustHostKey(unknown.HostKey))
+	assert.FileExists(t, knownHostsFile)
+	assert.NoError(t, checkKnownHost("git.company.com:7999", key))
+
+	var changed *HostKeyChangedError
+	require.ErrorAs(t, checkKnownHost("git.company.com:7999", other), &changed)
+	assert.ErrorContains(t, changed, "ssh-keygen -R [git.company.com]:7999")
+
+	// The same name on another port is another host
+	assert.ErrorAs(t, checkKnownHost("git.company.com", key), &unknown)
+}
+
+func TestSSHOptions_VerifyHostKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	key := signer.PublicKey()
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	t.Run("unknown hosts are rejected without a way to confirm them", func(t *testing.T) {
+		useKnownHosts(t)
+		var unknown *UnknownHostError
+		assert.ErrorAs(t, SSHOptions{}.VerifyHostKey(newHostKey("github.com:22", key)), &unknown)
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		useKnownHosts(t)
+		var asked HostKey
+		opts := SSHOptions{ConfirmHostKey: func(key HostKey) bool { asked = key; return false }}
+		var unknown *UnknownHostError
+		assert.ErrorAs(t, opts.VerifyHostKey(newHostKey("github.com:22", key)), &unknown)
+		assert.Equal(t, "github.com", asked.Host)
+		assert.Equal(t, fingerprint, asked.Fingerprint)
+	})
+
+	t.Run("confirmed keys are added to known_hosts", func(t *testing.T) {
+		useKnownHosts(t)
+		opts := SSHOptions{ConfirmHostKey: func(HostKey) bool { return true }}
+		require.NoError(t, opts.VerifyHostKey(newHostKey("github.com:22", key)))
+		assert.NoError(t, checkKnownHost("github.com", key))
+	})
+
+	t.Run("a matching pinned fingerprint is trusted without asking", func(t *testing.T) {
+		useKnownHosts(t)
+		opts := SSHOptions{HostKeyFingerprint: fingerprint, ConfirmHostKey: func(HostKey) bool {
+			t.Error("asked to confirm a pinned key")
+			return false
+		}}
+		require.NoError(t, opts.VerifyHostKey(newHostKey("github.com:22", key)))
+		assert.NoError(t, checkKnownHost("github.com", key))
+	})
+
+	t.Run("a key that doesn't match the pinned fingerprint is rejected", func(t *testing.T) {
+		knownHostsFile := useKnownHosts(t)
+		opts := SSHOptions{HostKeyFingerprint: "SHA256:pinned", ConfirmHostKey: func(HostKey) bool { return true }}
+		var changed *HostKeyChangedError
+		require.ErrorAs(t, opts.VerifyHostKey(newHostKey("github.com:22", key)), &changed)
+		assert.Equal(t, "SHA256:pinned", changed.Pinned)
+		assert.NoFileExists(t, knownHostsFile)
+	})
+}
+
+func TestCloneBareWithOptions_SSHHostKeys(t *testing.T) {
+	knownHostsFile := useKnownHosts(t)
+	useSSHConfig(t, "")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	identity, _ := writeKey(t, t.TempDir(), "id_test", "")
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "org", "repo.git")
+	_, err := RunGitCommandOutput("clone", "--bare", "--quiet", newHistory(t), repo)
+	require.NoError(t, err)
+	addr, _ := sshServer(t, root)
+	url := "ssh://git@" + addr + filepath.ToSlash(repo)
+
+	clone := func(opts SSHOptions) error {
+		path := filepath.Join(t.TempDir(), "repo.git")
+		return CloneBareWithOptions(url, path, io.Discard, CloneOptions{SSH: opts})
+	}
+
+	var unknown *UnknownHostError
+	err = clone(SSHOptions{IdentityFile: identity})
+	require.ErrorAs(t, err, &unknown)
+	assert.NoFileExists(t, knownHostsFile)
+
+	// Once confirmed the key is remembered
+	require.NoError(t, clone(SSHOptions{IdentityFile: identity, ConfirmHostKey: func(HostKey) bool { return true }}))
+	require.NoError(t, clone(SSHOptions{IdentityFile: identity}))
+
+	// A server presenting another key is reported as changed
+	require.NoError(t, os.WriteFile(knownHostsFile, nil, 0600))
+	_, otherKey := sshServer(t, root)
+	require.NoError(t, TrustHostKey(HostKey{Host: addr, key: otherKey}))
+	var changed *HostKeyChangedError
+	err = clone(SSHOptions{IdentityFile: identity})
+	assert.True(t, errors.As(err, &changed), "expected a changed host key, got %v", err)
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// identityKey lists the git config keys ConfigureCommitIdentity wrote, so they can be removed once
+// they are no longer configured
+const identityKey = "wt.identity"
+
+// CommitIdentity is the author identity and signing configuration of a repository. Empty settings
+// aren't written, so the user's global configuration applies.
+type CommitIdentity struct {
+	Name  string
+	Email string
+	// SigningKey is the user.signingKey: a GPG key ID, or the path of an SSH key
+	SigningKey string
+	// SigningFormat is the gpg.format the key is in, openpgp or ssh
+	SigningFormat string
+	// Sign sets commit.gpgSign when it is not nil
+	Sign *bool
+}
+
+// settings returns the git config keys and values the identity sets
+func (id CommitIdentity) settings() [][2]string {
+	var settings [][2]string
+	for _, s := range [][2]string{
+		{"user.name", id.Name},
+		{"user.email", id.Email},
+		{"user.signingKey", id.SigningKey},
+		{"gpg.format", id.SigningFormat},
+	} {
+		if s[1] != "" {
+			settings = append(settings, s)
+		}
+	}
+	if id.Sign != nil {
+		settings = append(settings, [2]string{"commit.gpgSign", strconv.FormatBool(*id.Sign)})
+	}
+	return settings
+}
+
+// IsZero reports whether the identity sets nothing
+func (id CommitIdentity) IsZero() bool {
+	return len(id.settings()) == 0
+}
+
+// ConfigureCommitIdentity writes id into the config of the repository at gitDir, so every worktree
+// commits with it. Settings an earlier call wrote that id no longer sets are removed; settings the
+// user made themselves are left alone.
+func ConfigureCommitIdentity(gitDir string, id CommitIdentity) error {
+	settings := id.settings()
+	written := map[string]bool{}
+	for _, s := range settings {
+		written[strings.ToLower(s[0])] = true
+	}
+
+	previous, _ := RunGitCommandOutput("--git-dir="+gitDir, "config", "--get-all", identityKey)
+	for _, key := range strings.Fields(previous) {
+		if written[strings.ToLower(key)] {
+			continue
+		}
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--local", "--get", key); err != nil {
+			continue
+		}
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--unset-all", key); err != nil {
+			return fmt.Errorf("failed to unset %s: %w", key, err)
+		}
+	}
+
+	for _, s := range settings {
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", s[0], s[1]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", s[0], err)
+		}
+	}
+
+	if previous != "" {
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--unset-all", identityKey); err != nil {
+			return fmt.Errorf("failed to update %s: %w", identityKey, err)
+		}
+	}
+	for _, s := range settings {
+		if _, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "--add", identityKey, s[0]); err != nil {
+			return fmt.Errorf("failed to record %s: %w", s[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureCommitIdentity(t *testing.T) {
+	gitDir := filepath.Join(t.TempDir(), ".bare")
+	require.NoError(t, RunGitCommandInDirTo(io.Discard, ".", "init", "--bare", gitDir))
+	get := func(key string) string {
+		value, _ := RunGitCommandOutput("--git-dir="+gitDir, "config", "--local", key)
+		return value
+	}
+
+	sign := true
+	require.NoError(t, ConfigureCommitIdentity(gitDir, CommitIdentity{
+		Name:          "Jane Doe",
+		Email:         "jane@example.com",
+		SigningKey:    "/home/jane/.ssh/id_work.pub",
+		SigningFormat: "ssh",
+		Sign:          &sign,
+	}))
+	assert.Equal(t, "Jane Doe", get("user.name"))
+	assert.Equal(t, "jane@example.com", get("user.email"))
+	assert.Equal(t, "/home/jane/.ssh/id_work.pub", get("user.signingkey"))
+	assert.Equal(t, "ssh", get("gpg.format"))
+	assert.Equal(t, "true", get("commit.gpgsign"))
+
+	// Settings written before that are no longer given are removed
+	sign = false
+	require.NoError(t, ConfigureCommitIdentity(gitDir, CommitIdentity{Email: "jane@acme.com", Sign: &sign}))
+	assert.Empty(t, get("user.name"))
+	assert.Equal(t, "jane@acme.com", get("user.email"))
+	assert.Empty(t, get("user.signingkey"))
+	assert.Empty(t, get("gpg.format"))
+	assert.Equal(t, "false", get("commit.gpgsign"))
+
+	// Settings the user made themselves are left alone
+	_, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "user.name", "Jane")
+	require.NoError(t, err)
+	require.NoError(t, ConfigureCommitIdentity(gitDir, CommitIdentity{}))
+	assert.Equal(t, "Jane", get("user.name"))
+	assert.Empty(t, get("user.email"))
+	assert.Empty(t, get("commit.gpgsign"))
+	assert.Empty(t, get(identityKey))
+}
+
+func TestCommitIdentity_IsZero(t *testing.T) {
+	assert.True(t, CommitIdentity{}.IsZero())
+	sign := false
+	assert.False(t, CommitIdentity{Sign: &sign}.IsZero())
+	assert.False(t, CommitIdentity{Email: "jane@example.com"}.IsZero())
+}
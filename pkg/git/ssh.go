@@ -0,0 +1,239 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/kevinburke/ssh_config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// scpLikeURL matches scp-style addresses such as git@github.com:org/repo.git, capturing the user and
+// host. The host may be an ssh_config Host alias.
+var scpLikeURL = regexp.MustCompile(`^(?:([^@/:]+)@)?([^@/:]+):([^/].*)$`)
+
+// sshConfig resolves settings of a host alias from the user's and the system's ssh_config files.
+// go-git resolves HostName and Port itself; User and IdentityFile are resolved here.
+var sshConfig interface {
+	Get(alias, key string) string
+	GetAll(alias, key string) []string
+} = ssh_config.DefaultUserSettings
+
+// SSHOptions configure how SSH connections authenticate
+type SSHOptions struct {
+	// IdentityFile is the private key to authenticate with. When it is empty the IdentityFile of the
+	// host's ssh_config entry, the SSH agent and the default keys are tried.
+	IdentityFile string
+	// Passphrase decrypts the identity file. Without it an encrypted key is only usable when the
+	// SSH agent holds it and its public key is next to it.
+	Passphrase string
+	// HostKeyFingerprint pins the SHA256 fingerprint a host's key must have before it is added to
+	// known_hosts
+	HostKeyFingerprint string
+	// ConfirmHostKey is asked whether to add the key of an unknown host to known_hosts when no
+	// fingerprint is pinned. Unknown hosts are rejected when it is nil.
+	ConfirmHostKey func(HostKey) bool
+}
+
+// SSHCommand returns the core.sshCommand that makes the git CLI authenticate with opts' identity
+// file, or "" when ssh's own configuration is enough
+func (o SSHOptions) SSHCommand() string {
+	if o.IdentityFile == "" {
+		return ""
+	}
+	return fmt.Sprintf("ssh -i '%s' -o IdentitiesOnly=yes", strings.ReplaceAll(o.IdentityFile, "'", `'\''`))
+}
+
+// ConfigureSSHCommand makes git in the repository at gitDir authenticate with opts' identity file
+func ConfigureSSHCommand(gitDir string, opts SSHOptions) error {
+	command := opts.SSHCommand()
+	if command == "" {
+		return nil
+	}
+	_, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "core.sshCommand", command)
+	return err
+}
+
+// isSSHURL reports whether url is an ssh:// URL or an scp-style address such as git@host:org/repo.git
+func isSSHURL(url string) bool {
+	return strings.HasPrefix(url, "ssh://") || scpLikeURL.MatchString(url)
+}
+
+// sshUserHost returns the user and host alias of an SSH URL. The user is empty when the URL has none.
+func sshUserHost(rawURL string) (user, alias string) {
+	if m := scpLikeURL.FindStringSubmatch(rawURL); m != nil {
+		return m[1], m[2]
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.User.Username(), u.Hostname()
+	}
+	return "", ""
+}
+
+// sshHost returns the host alias of an SSH URL with its port, when the URL has one
+func sshHost(rawURL string) string {
+	if m := scpLikeURL.FindStringSubmatch(rawURL); m != nil {
+		return m[2]
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
+// getSSHAuth configures SSH authentication for url. The user comes from the URL or the host's
+// ssh_config entry, defaulting to git. A configured identity file is used exclusively, so a host
+// with its own key never sees the keys of other accounts; otherwise the SSH agent and the default
+// key locations are tried.
+func getSSHAuth(url string, opts SSHOptions) (transport.AuthMethod, error) {
+	user, alias := sshUserHost(url)
+	if user == "" {
+		user = sshConfig.Get(alias, "User")
+	}
+	if user == "" {
+		user = "git"
+	}
+
+	if identities := identityFiles(alias, opts); len(identities) > 0 {
+		var errs []string
+		for _, path := range identities {
+			auth, err := gitssh.NewPublicKeysFromFile(user, path, opts.Passphrase)
+			if err == nil {
+				return auth, nil
+			}
+			// A key protected by a passphrase may still be unlocked in the agent
+			if auth, agentErr := agentAuthForKey(user, path); agentErr == nil {
+				return auth, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+		return nil, fmt.Errorf("failed to load SSH identity (%s), configure its passphrase with 'wt config set-ssh' or add it to the SSH agent", strings.Join(errs, "; "))
+	}
+
+	// Try to connect to SSH agent using SSH_AUTH_SOCK
+	if authSock := os.Getenv("SSH_AUTH_SOCK"); authSock != "" {
+		conn, err := net.Dial("unix", authSock)
+		if err == nil {
+			agentClient := agent.NewClient(conn)
+			keys, err := agentClient.List()
+			if err == nil && len(keys) > 0 {
+				auth, err := gitssh.NewSSHAgentAuth(user)
+				if err == nil {
+					return auth, nil
+				}
+			}
+			_ = conn.Close()
+		}
+	}
+
+	// Fallback to go-git's SSH agent implementation
+	auth, err := gitssh.NewSSHAgentAuth(user)
+	if err == nil {
+		return auth, nil
+	}
+
+	// Fallback to default SSH key locations
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	// Try common SSH key locations
+	keyPaths := []string{
+		filepath.Join(homeDir, ".ssh", "id_rsa"),
+		filepath.Join(homeDir, ".ssh", "id_ed25519"),
+		filepath.Join(homeDir, ".ssh", "id_ecdsa"),
+	}
+
+	for _, keyPath := range keyPaths {
+		if _, err := os.Stat(keyPath); err == nil {
+			auth, err := gitssh.NewPublicKeysFromFile(user, keyPath, "")
+			if err == nil {
+				return auth, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no SSH authentication method available (tried SSH agent and common key locations)")
+}
+
+// identityFiles returns the identity files to authenticate to alias with: the configured one, or
+// those of the host's ssh_config entry that exist. ssh's built-in default is not included.
+func identityFiles(alias string, opts SSHOptions) []string {
+	if opts.IdentityFile != "" {
+		return []string{opts.IdentityFile}
+	}
+	var paths []string
+	for _, path := range sshConfig.GetAll(alias, "IdentityFile") {
+		if path == ssh_config.Default("IdentityFile") {
+			continue
+		}
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			path = filepath.Join(home, path[2:])
+		}
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// agentAuthForKey authenticates with the SSH agent's copy of the key at path, identified by the
+// public key next to it
+func agentAuthForKey(user, path string) (transport.AuthMethod, error) {
+	data, err := os.ReadFile(path + ".pub")
+	if err != nil {
+		return nil, err
+	}
+	want, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	authSock := os.Getenv("SSH_AUTH_SOCK")
+	if authSock == "" {
+		return nil, fmt.Errorf("no SSH agent")
+	}
+	conn, err := net.Dial("unix", authSock)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), want.Marshal()) {
+			return &gitssh.PublicKeys{User: user, Signer: signer}, nil
+		}
+	}
+	_ = conn.Close()
+	return nil, fmt.Errorf("the SSH agent doesn't hold %s", path)
+}
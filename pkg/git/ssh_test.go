@@ -0,0 +1,213 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/kevinburke/ssh_config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// testSSHConfig serves settings from an ssh_config file written by the test
+type testSSHConfig struct{ cfg *ssh_config.Config }
+
+func (c testSSHConfig) Get(alias, key string) string {
+	value, _ := c.cfg.Get(alias, key)
+	return value
+}
+
+func (c testSSHConfig) GetAll(alias, key string) []string {
+	values, _ := c.cfg.GetAll(alias, key)
+	return values
+}
+
+// useSSHConfig resolves hosts from the given ssh_config contents for the rest of the test
+func useSSHConfig(t *testing.T, contents string) {
+	t.Helper()
+	cfg, err := ssh_config.Decode(strings.NewReader(contents))
+	require.NoError(t, err)
+	previous := sshConfig
+	sshConfig = testSSHConfig{cfg}
+	t.Cleanup(func() { sshConfig = previous })
+}
+
+// writeKey writes an ed25519 key pair to dir, encrypting the private key when passphrase isn't empty
+func writeKey(t *testing.T, dir, name, passphrase string) (string, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	require.NoError(t, os.WriteFile(path+".pub", ssh.MarshalAuthorizedKey(sshPub), 0644))
+	return path, priv
+}
+
+// startAgent serves an SSH agent holding key for the rest of the test
+func startAgent(t *testing.T, key ed25519.PrivateKey) {
+	t.Helper()
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: key}))
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+}
+
+func TestIsSSHURL(t *testing.T) {
+	assert.True(t, isSSHURL("git@github.com:org/repo.git"))
+	assert.True(t, isSSHURL("deploy@work-github:org/repo.git"))
+	assert.True(t, isSSHURL("work-github:org/repo.git"))
+	assert.True(t, isSSHURL("ssh://git@git.company.com:7999/org/repo.git"))
+	assert.False(t, isSSHURL("https://github.com/org/repo.git"))
+	assert.False(t, isSSHURL("/tmp/repo"))
+	assert.False(t, isSSHURL("./dir:with/colon"))
+}
+
+func TestSSHUserHost(t *testing.T) {
+	tests := []struct {
+		url, user, alias string
+	}{
+		{"git@github.com:org/repo.git", "git", "github.com"},
+		{"work-github:org/repo.git", "", "work-github"},
+		{"ssh://deploy@git.company.com:7999/org/repo.git", "deploy", "git.company.com"},
+		{"ssh://git.company.com/org/repo.git", "", "git.company.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			user, alias := sshUserHost(tt.url)
+			assert.Equal(t, tt.user, user)
+			assert.Equal(t, tt.alias, alias)
+		})
+	}
+}
+
+func TestGetSSHAuth(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSH_AUTH_SOCK", "")
+	workKey, _ := writeKey(t, dir, "id_work", "")
+	lockedKey, lockedPriv := writeKey(t, dir, "id_locked", "secret")
+	useSSHConfig(t, `
+Host work-github
+  HostName github.com
+  User work
+  IdentityFile `+workKey+`
+
+Host missing-key
+  IdentityFile `+filepath.Join(dir, "id_missing")+`
+`)
+
+	publicKeys := func(t *testing.T, auth transport.AuthMethod) *gitssh.PublicKeys {
+		t.Helper()
+		keys, ok := auth.(*gitssh.PublicKeys)
+		require.True(t, ok, "expected public key auth, got %T", auth)
+		return keys
+	}
+
+	t.Run("ssh_config user and identity of a host alias", func(t *testing.T) {
+		auth, err := getSSHAuth("work-github:org/repo.git", SSHOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "work", publicKeys(t, auth).User)
+	})
+
+	t.Run("the URL's user wins over ssh_config", func(t *testing.T) {
+		auth, err := getSSHAuth("ssh://deploy@work-github:2222/org/repo.git", SSHOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "deploy", publicKeys(t, auth).User)
+	})
+
+	t.Run("configured identity with its passphrase", func(t *testing.T) {
+		auth, err := getSSHAuth("git@github.com:org/repo.git", SSHOptions{IdentityFile: lockedKey, Passphrase: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, "git", publicKeys(t, auth).User)
+	})
+
+	t.Run("configured identity without its passphrase", func(t *testing.T) {
+		_, err := getSSHAuth("git@github.com:org/repo.git", SSHOptions{IdentityFile: lockedKey})
+		assert.ErrorContains(t, err, "wt config set-ssh")
+	})
+
+	t.Run("configured identity unlocked in the agent", func(t *testing.T) {
+		startAgent(t, lockedPriv)
+		auth, err := getSSHAuth("git@github.com:org/repo.git", SSHOptions{IdentityFile: lockedKey})
+		require.NoError(t, err)
+		want, err := os.ReadFile(lockedKey + ".pub")
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(string(want)), strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKeys(t, auth).Signer.PublicKey()))))
+	})
+
+	t.Run("missing ssh_config identities are skipped", func(t *testing.T) {
+		assert.Empty(t, identityFiles("missing-key", SSHOptions{}))
+		assert.Empty(t, identityFiles("github.com", SSHOptions{}))
+	})
+}
+
+func TestSSHOptions_SSHCommand(t *testing.T) {
+	assert.Empty(t, SSHOptions{}.SSHCommand())
+	assert.Equal(t, `ssh -i '/keys/id_work' -o IdentitiesOnly=yes`, SSHOptions{IdentityFile: "/keys/id_work"}.SSHCommand())
+	assert.Equal(t, `ssh -i '/keys/it'\''s' -o IdentitiesOnly=yes`, SSHOptions{IdentityFile: "/keys/it's"}.SSHCommand())
+}
+
+func TestConfigureSSHCommand(t *testing.T) {
+	gitDir := filepath.Join(t.TempDir(), "repo.git")
+	_, err := RunGitCommandOutput("init", "--bare", "--quiet", gitDir)
+	require.NoError(t, err)
+
+	require.NoError(t, ConfigureSSHCommand(gitDir, SSHOptions{}))
+	_, err = RunGitCommandOutput("--git-dir="+gitDir, "config", "core.sshCommand")
+	assert.Error(t, err, "nothing is recorded without an identity file")
+
+	require.NoError(t, ConfigureSSHCommand(gitDir, SSHOptions{IdentityFile: "/keys/id_work"}))
+	command, err := RunGitCommandOutput("--git-dir="+gitDir, "config", "core.sshCommand")
+	require.NoError(t, err)
+	assert.Equal(t, "ssh -i '/keys/id_work' -o IdentitiesOnly=yes", command)
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress reports the progress of long running git operations such as clones and fetches.
+// Output from git and go-git is turned into Events by a Writer and rendered by a Reporter: progress
+// bars on a terminal, plain lines in CI, or a JSON-lines stream for wrappers and editors.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// EventType distinguishes messages from progress updates
+type EventType string
+
+const (
+	// EventMessage is a line of output, such as a step setup is about to take
+	EventMessage EventType = "message"
+	// EventProgress is an update to a phase of a git operation, such as receiving objects
+	EventProgress EventType = "progress"
+)
+
+// Event is a message or progress update from a long running operation
+type Event struct {
+	Type EventType `json:"type"`
+	// Repo identifies the repository when several are worked on at once
+	Repo    string `json:"repo,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Phase is the git progress phase, e.g. "Receiving objects"
+	Phase string `json:"phase,omitempty"`
+	// Objects is how many objects the phase has processed, out of Total when known
+	Objects int64 `json:"objects,omitempty"`
+	Total   int64 `json:"total,omitempty"`
+	// Bytes is how much data the phase has transferred
+	Bytes   int64 `json:"bytes,omitempty"`
+	Percent int   `json:"percent"`
+	// Done is set on the last update of a phase
+	Done bool `json:"done,omitempty"`
+}
+
+// String renders the event as a line of text, the way git prints it
+func (e Event) String() string {
+	var line string
+	switch {
+	case e.Type == EventMessage:
+		line = e.Message
+	case e.Total > 0:
+		line = fmt.Sprintf("%s: %3d%% (%d/%d)", e.Phase, e.Percent, e.Objects, e.Total)
+	default:
+		line = fmt.Sprintf("%s: %d", e.Phase, e.Objects)
+	}
+	if e.Bytes > 0 {
+		line += ", " + formatBytes(e.Bytes)
+	}
+	if e.Done {
+		line += ", done."
+	}
+	if e.Repo != "" {
+		line = "[" + e.Repo + "] " + line
+	}
+	return line
+}
+
+// Reporter renders events. Implementations are safe for concurrent use.
+type Reporter interface {
+	Report(e Event)
+	// Close stops rendering, waiting for pending output to be written. Closing more than once is allowed.
+	Close() error
+}
+
+// ReporterFunc adapts a function to a Reporter that needs no closing
+type ReporterFunc func(e Event)
+
+// Report calls f
+func (f ReporterFunc) Report(e Event) { f(e) }
+
+// Close does nothing
+func (f ReporterFunc) Close() error { return nil }
+
+// Mode selects how progress is rendered
+type Mode string
+
+const (
+	// ModeAuto uses progress bars on a terminal and plain lines otherwise
+	ModeAuto Mode = "auto"
+	// ModeTTY draws progress bars with Bubble Tea
+	ModeTTY Mode = "tty"
+	// ModePlain prints messages and a line for each completed phase
+	ModePlain Mode = "plain"
+	// ModeQuiet prints messages only
+	ModeQuiet Mode = "quiet"
+	// ModeJSON writes every event as a line of JSON
+	ModeJSON Mode = "json"
+)
+
+// ParseMode parses a progress mode
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeAuto, ModeTTY, ModePlain, ModeQuiet, ModeJSON:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid progress mode: %s (valid options: auto, tty, plain, quiet, json)", s)
+	}
+}
+
+// New returns the reporter for mode writing to out. Auto uses progress bars when out is a terminal.
+func New(mode Mode, out io.Writer) (Reporter, error) {
+	switch mode {
+	case "", ModeAuto:
+		if isTerminal(out) {
+			return NewTTY(out), nil
+		}
+		return NewPlain(out), nil
+	case ModeTTY:
+		return NewTTY(out), nil
+	case ModePlain:
+		return NewPlain(out), nil
+	case ModeQuiet:
+		return NewQuiet(out), nil
+	case ModeJSON:
+		return NewJSON(out), nil
+	default:
+		return nil, fmt.Errorf("unknown progress mode: %s", mode)
+	}
+}
+
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.2f %s", value, suffix)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Event
+	}{
+		{
+			line: "Cloning into bare repository 'repo'...",
+			want: Event{Type: EventMessage, Message: "Cloning into bare repository 'repo'..."},
+		},
+		{
+			line: "remote: Enumerating objects: 1234, done.",
+			want: Event{Type: EventProgress, Phase: "Enumerating objects", Objects: 1234, Percent: 100, Done: true},
+		},
+		{
+			line: "Counting objects:  50% (2/4)",
+			want: Event{Type: EventProgress, Phase: "Counting objects", Objects: 2, Total: 4, Percent: 50},
+		},
+		{
+			line: "Receiving objects:  45% (450/1000), 1.50 MiB | 2.00 MiB/s",
+			want: Event{Type: EventProgress, Phase: "Receiving objects", Objects: 450, Total: 1000, Bytes: 1572864, Percent: 45},
+		},
+		{
+			line: "Resolving deltas: 100% (300/300), done.",
+			want: Event{Type: EventProgress, Phase: "Resolving deltas", Objects: 300, Total: 300, Percent: 100, Done: true},
+		},
+		{
+			line: "remote: Total 1000 (delta 300), reused 0 (delta 0)",
+			want: Event{Type: EventMessage, Message: "remote: Total 1000 (delta 300), reused 0 (delta 0)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.line))
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var events []Event
+	w := NewWriter(ReporterFunc(func(e Event) { events = append(events, e) }), "repo")
+
+	_, _ = w.Write([]byte("Cloning\nReceiving objects:  50% (1/2)\rReceiving objects: 100% (2/2), done.\r\n\nDo"))
+	_, _ = w.Write([]byte("ne"))
+	w.Flush()
+
+	require.Len(t, events, 4)
+	assert.Equal(t, Event{Type: EventMessage, Repo: "repo", Message: "Cloning"}, events[0])
+	assert.Equal(t, 50, events[1].Percent)
+	assert.True(t, events[2].Done)
+	assert.Equal(t, "Done", events[3].Message)
+}
+
+func TestPlain(t *testing.T) {
+	var out strings.Builder
+	w := NewWriter(NewPlain(&out), "repo")
+
+	_, _ = w.Write([]byte("Cloning\nCounting objects: 50% (1/2)\rCounting objects: 100% (2/2), done.\r\nReceiving objects: 10% (1/10), 1.00 KiB\r"))
+	w.Flush()
+
+	assert.Equal(t, "[repo] Cloning\n[repo] Counting objects: 100% (2/2), done.\n", out.String())
+}
+
+func TestQuiet(t *testing.T) {
+	var out strings.Builder
+	w := NewWriter(NewQuiet(&out), "")
+
+	_, _ = w.Write([]byte("Cloning\nCounting objects: 100% (2/2), done.\n"))
+
+	assert.Equal(t, "Cloning\n", out.String())
+}
+
+func TestJSON(t *testing.T) {
+	var out strings.Builder
+	w := NewWriter(NewJSON(&out), "repo")
+
+	_, _ = w.Write([]byte("Cloning\nReceiving objects:  45% (450/1000), 1.00 MiB | 2.00 MiB/s\r"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"type":"message","repo":"repo","message":"Cloning","percent":0}`, lines[0])
+
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, Event{Type: EventProgress, Repo: "repo", Phase: "Receiving objects", Objects: 450, Total: 1000, Bytes: 1 << 20, Percent: 45}, e)
+}
+
+func TestBarsModel(t *testing.T) {
+	m := newBarsModel()
+	update := func(e Event) {
+		next, _ := m.Update(e)
+		m = next.(barsModel)
+	}
+	update(Event{Type: EventProgress, Repo: "a", Phase: "Receiving objects", Objects: 5, Total: 10, Percent: 50})
+	update(Event{Type: EventProgress, Repo: "b", Phase: "Enumerating objects", Objects: 7})
+
+	lines := strings.Split(strings.TrimSpace(m.View()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "[a] Receiving objects")
+	assert.Contains(t, lines[0], "50% (5/10)")
+	assert.Equal(t, "[b] Enumerating objects 7", lines[1])
+
+	update(Event{Type: EventProgress, Repo: "a", Phase: "Receiving objects", Objects: 10, Total: 10, Percent: 100, Done: true})
+	assert.Equal(t, "[b] Enumerating objects 7\n", m.View())
+
+	// A late update of a finished phase doesn't bring its bar back
+	update(Event{Type: EventProgress, Repo: "a", Phase: "Receiving objects", Objects: 10, Total: 10, Percent: 100})
+	assert.Equal(t, "[b] Enumerating objects 7\n", m.View())
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("json")
+	require.NoError(t, err)
+	assert.Equal(t, ModeJSON, mode)
+
+	_, err = ParseMode("fancy")
+	assert.ErrorContains(t, err, "invalid progress mode")
+}
+
+func TestNew_AutoWithoutTerminal(t *testing.T) {
+	r, err := New(ModeAuto, &strings.Builder{})
+	require.NoError(t, err)
+	assert.IsType(t, &lineReporter{}, r)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// lineReporter prints events as lines of text
+type lineReporter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	progress bool
+}
+
+// NewPlain returns a reporter that prints messages and a line for each completed phase, for logs and CI
+func NewPlain(out io.Writer) Reporter {
+	return &lineReporter{out: out, progress: true}
+}
+
+// NewQuiet returns a reporter that prints messages and leaves out progress
+func NewQuiet(out io.Writer) Reporter {
+	return &lineReporter{out: out}
+}
+
+func (r *lineReporter) Report(e Event) {
+	if e.Type == EventProgress && (!r.progress || !e.Done) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = fmt.Fprintln(r.out, e)
+}
+
+func (r *lineReporter) Close() error { return nil }
+
+// jsonReporter writes every event as a line of JSON
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSON returns a reporter that writes every event to out as a line of JSON
+func NewJSON(out io.Writer) Reporter {
+	return &jsonReporter{enc: json.NewEncoder(out)}
+}
+
+func (r *jsonReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(e)
+}
+
+func (r *jsonReporter) Close() error { return nil }
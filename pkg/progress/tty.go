@@ -0,0 +1,149 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// barWidth is the width of each progress bar in cells
+const barWidth = 30
+
+// ttyReporter draws a progress bar for each repository's current phase. Messages and completed
+// phases are printed above the bars so they stay in the scrollback. Once the bars are gone, for
+// example to make way for a prompt, events are printed as plain lines.
+type ttyReporter struct {
+	mu      sync.Mutex
+	program *tea.Program
+	done    chan struct{}
+	closed  bool
+	plain   Reporter
+}
+
+// NewTTY returns a reporter that draws progress bars on the terminal out
+func NewTTY(out io.Writer) Reporter {
+	r := &ttyReporter{
+		// Input is left alone so prompts and Ctrl+C keep working while the bars are drawn
+		program: tea.NewProgram(newBarsModel(), tea.WithOutput(out), tea.WithInput(nil), tea.WithoutSignalHandler()),
+		done:    make(chan struct{}),
+		plain:   NewPlain(out),
+	}
+	go func() {
+		_, _ = r.program.Run()
+		close(r.done)
+	}()
+	return r
+}
+
+func (r *ttyReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.done:
+		// The program stopped, so nothing would read what is sent
+		r.plain.Report(e)
+		return
+	default:
+	}
+	// Lines are printed through the program so they are ordered with the bars' updates
+	if e.Type == EventMessage || e.Done {
+		r.program.Println(e.String())
+	}
+	if e.Type == EventProgress {
+		r.program.Send(e)
+	}
+}
+
+func (r *ttyReporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.program.Quit()
+		<-r.done
+	}
+	return nil
+}
+
+// barsModel holds the latest unfinished progress of each repository
+type barsModel struct {
+	bar    progress.Model
+	repos  []string
+	phases map[string]Event
+	// finished is the last completed phase of each repository. Git can send one more update after
+	// a phase is done, which must not bring its bar back.
+	finished map[string]string
+}
+
+func newBarsModel() barsModel {
+	return barsModel{
+		bar:      progress.New(progress.WithDefaultGradient(), progress.WithWidth(barWidth), progress.WithoutPercentage()),
+		phases:   map[string]Event{},
+		finished: map[string]string{},
+	}
+}
+
+func (m barsModel) Init() tea.Cmd { return nil }
+
+func (m barsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	e, ok := msg.(Event)
+	if !ok || (!e.Done && m.finished[e.Repo] == e.Phase) {
+		return m, nil
+	}
+	if _, seen := m.phases[e.Repo]; !seen && !e.Done {
+		m.repos = append(m.repos, e.Repo)
+	}
+	if e.Done {
+		m.finished[e.Repo] = e.Phase
+		delete(m.phases, e.Repo)
+		for i, repo := range m.repos {
+			if repo == e.Repo {
+				m.repos = append(m.repos[:i:i], m.repos[i+1:]...)
+				break
+			}
+		}
+		return m, nil
+	}
+	m.finished[e.Repo] = ""
+	m.phases[e.Repo] = e
+	return m, nil
+}
+
+func (m barsModel) View() string {
+	var b strings.Builder
+	for _, repo := range m.repos {
+		e := m.phases[repo]
+		if repo != "" {
+			b.WriteString("[" + repo + "] ")
+		}
+		b.WriteString(e.Phase + " ")
+		if e.Total > 0 {
+			b.WriteString(m.bar.ViewAs(float64(e.Percent)/100) + fmt.Sprintf(" %3d%% (%d/%d)", e.Percent, e.Objects, e.Total))
+		} else {
+			b.WriteString(fmt.Sprintf("%d", e.Objects))
+		}
+		if e.Bytes > 0 {
+			b.WriteString(", " + formatBytes(e.Bytes))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// progressLine matches git progress such as "Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s"
+// or "remote: Enumerating objects: 1234, done.". Servers send the same lines to go-git without the prefix.
+var progressLine = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z]+(?: [a-z]+)*): +(?:(\d+)% \((\d+)/(\d+)\)|(\d+))(.*)$`)
+
+// transferred matches the amount of data in the rest of a progress line
+var transferred = regexp.MustCompile(`^, (\d+(?:\.\d+)?) (bytes|KiB|MiB|GiB|TiB)`)
+
+// Writer turns the output of git and go-git into events for a Reporter. Git redraws progress lines
+// in place by ending them with a carriage return; each of those becomes a progress event and every
+// other line a message.
+type Writer struct {
+	reporter Reporter
+	repo     string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns a Writer reporting to r, marking events with repo when it is not empty
+func NewWriter(r Reporter, repo string) *Writer {
+	return &Writer{reporter: r, repo: repo}
+}
+
+// Write reports every complete line in p and buffers the rest
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := strings.IndexAny(string(w.buf), "\r\n")
+		if i < 0 {
+			break
+		}
+		w.report(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush reports any trailing output that didn't end with a newline
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.report(string(w.buf))
+		w.buf = nil
+	}
+}
+
+func (w *Writer) report(line string) {
+	line = strings.TrimRight(line, " ")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	e := Parse(line)
+	e.Repo = w.repo
+	w.reporter.Report(e)
+}
+
+// Parse turns a line of git output into a progress event, or a message when it isn't progress
+func Parse(line string) Event {
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return Event{Type: EventMessage, Message: line}
+	}
+
+	e := Event{Type: EventProgress, Phase: m[1]}
+	if m[2] != "" {
+		e.Percent, _ = strconv.Atoi(m[2])
+		e.Objects, _ = strconv.ParseInt(m[3], 10, 64)
+		e.Total, _ = strconv.ParseInt(m[4], 10, 64)
+	} else {
+		e.Objects, _ = strconv.ParseInt(m[5], 10, 64)
+	}
+
+	rest := m[6]
+	if b := transferred.FindStringSubmatch(rest); b != nil {
+		e.Bytes = parseBytes(b[1], b[2])
+	}
+	if strings.HasSuffix(rest, "done.") || strings.HasSuffix(rest, "done") {
+		e.Done = true
+		e.Percent = 100
+	}
+	return e
+}
+
+var unitSizes = map[string]float64{"bytes": 1, "KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40}
+
+func parseBytes(value, unit string) int64 {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n * unitSizes[unit])
+}
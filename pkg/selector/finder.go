@@ -0,0 +1,104 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Finder is a selector backend that delegates to an external fuzzy finder such as fzf or sk.
+// Confirmation is not something finders do well, so it is handled by the embedded prompt.
+type Finder struct {
+	// Binary is the finder executable, looked up in PATH
+	Binary string
+	// PreviewCommand is passed to --preview; {1} is the worktree path
+	PreviewCommand string
+
+	Prompt
+}
+
+// args builds the finder command line. Each input line is "path\tlabel" and only the label is shown.
+func (f Finder) args(title string, multi bool) []string {
+	args := []string{
+		"--delimiter=\t",
+		"--with-nth=2..",
+		"--prompt=" + title + " ",
+		"--layout=reverse",
+		"--height=40%",
+	}
+	if multi {
+		args = append(args, "--multi")
+	}
+	if f.PreviewCommand != "" {
+		args = append(args, "--preview="+f.PreviewCommand)
+	}
+	return args
+}
+
+func (f Finder) run(title string, items []Item, multi bool) ([]string, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no options provided")
+	}
+
+	binary, err := exec.LookPath(f.Binary)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in PATH: %w", f.Binary, err)
+	}
+
+	var input bytes.Buffer
+	for _, item := range f.withStatus(items) {
+		fmt.Fprintf(&input, "%s\t%s\n", item.Path, item.Label())
+	}
+
+	cmd := exec.Command(binary, f.args(title, multi)...)
+	cmd.Stdin = &input
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// fzf and sk exit with 1 when nothing matched and 130 when the user aborted
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to run %s: %w", f.Binary, err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if path, _, _ := strings.Cut(line, "\t"); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// Select returns the path of the chosen worktree or empty string if cancelled
+func (f Finder) Select(title string, items []Item) (string, error) {
+	paths, err := f.run(title, items, false)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// SelectMany returns the paths of the chosen worktrees or nil if cancelled
+func (f Finder) SelectMany(title string, items []Item) ([]string, error) {
+	return f.run(title, items, true)
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFinder writes a script that behaves like fzf: it records its arguments and
+// prints the input lines selected by the given sed expression
+func fakeFinder(t *testing.T, script string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	binary := filepath.Join(dir, "fakefzf")
+	content := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\n" + script + "\n"
+	require.NoError(t, os.WriteFile(binary, []byte(content), 0755))
+	return binary, argsFile
+}
+
+func TestFinder_Select(t *testing.T) {
+	binary, argsFile := fakeFinder(t, "sed -n 2p")
+	f := Finder{Binary: binary, PreviewCommand: "wt preview {1}"}
+
+	got, err := f.Select("switch>", promptItems)
+	require.NoError(t, err)
+	assert.Equal(t, "/repo/two", got)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--with-nth=2..")
+	assert.Contains(t, string(args), "--preview=wt preview {1}")
+	assert.NotContains(t, string(args), "--multi")
+}
+
+func TestFinder_SelectMany(t *testing.T) {
+	binary, argsFile := fakeFinder(t, "sed -n '1p;3p'")
+	f := Finder{Binary: binary}
+
+	got, err := f.SelectMany("remove>", promptItems)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/repo/one", "/repo/three"}, got)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "--multi")
+	assert.NotContains(t, string(args), "--preview")
+}
+
+func TestFinder_Cancelled(t *testing.T) {
+	binary, _ := fakeFinder(t, "exit 130")
+	f := Finder{Binary: binary}
+
+	got, err := f.Select("switch>", promptItems)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestFinder_MissingBinary(t *testing.T) {
+	f := Finder{Binary: "definitely-not-a-real-finder"}
+	_, err := f.Select("switch>", promptItems)
+	assert.ErrorContains(t, err, "not found in PATH")
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/liamawhite/worktree/pkg/config"
+)
+
+// KeyMap holds the key bindings used by the TUI
+type KeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Choose    key.Binding
+	Quit      key.Binding
+	Filter    key.Binding
+	Toggle    key.Binding
+	ToggleAll key.Binding
+}
+
+// keymapPresets lists the keys of each preset in the order up, down, choose, quit, filter, toggle, toggle all
+var keymapPresets = map[string]config.SelectorKeys{
+	config.KeymapDefault: {
+		Up:        []string{"up", "k"},
+		Down:      []string{"down", "j"},
+		Choose:    []string{"enter"},
+		Quit:      []string{"q", "ctrl+c"},
+		Filter:    []string{"/"},
+		Toggle:    []string{"space"},
+		ToggleAll: []string{"a"},
+	},
+	config.KeymapVim: {
+		Up:        []string{"up", "k", "ctrl+k"},
+		Down:      []string{"down", "j", "ctrl+j"},
+		Choose:    []string{"enter"},
+		Quit:      []string{"q", "esc", "ctrl+c"},
+		Filter:    []string{"/"},
+		Toggle:    []string{"space"},
+		ToggleAll: []string{"a"},
+	},
+	config.KeymapEmacs: {
+		Up:        []string{"up", "ctrl+p"},
+		Down:      []string{"down", "ctrl+n"},
+		Choose:    []string{"enter", "ctrl+m"},
+		Quit:      []string{"ctrl+g", "ctrl+c"},
+		Filter:    []string{"ctrl+s", "/"},
+		Toggle:    []string{"space"},
+		ToggleAll: []string{"a"},
+	},
+}
+
+// DefaultKeyMap returns the key map used when nothing is configured
+func DefaultKeyMap() KeyMap {
+	keys, _ := NewKeyMap(config.SelectorConfig{})
+	return keys
+}
+
+// NewKeyMap builds the key map from the selector configuration
+func NewKeyMap(cfg config.SelectorConfig) (KeyMap, error) {
+	name := cfg.Keymap
+	if name == "" {
+		name = config.KeymapDefault
+	}
+	preset, ok := keymapPresets[name]
+	if !ok {
+		return KeyMap{}, fmt.Errorf("unknown selector keymap: %s (valid options: default, vim, emacs)", name)
+	}
+
+	pick := func(override, fallback []string) []string {
+		if len(override) > 0 {
+			return override
+		}
+		return fallback
+	}
+
+	return KeyMap{
+		Up:        binding(pick(cfg.Keys.Up, preset.Up), "up"),
+		Down:      binding(pick(cfg.Keys.Down, preset.Down), "down"),
+		Choose:    binding(pick(cfg.Keys.Choose, preset.Choose), "choose"),
+		Quit:      binding(pick(cfg.Keys.Quit, preset.Quit), "quit"),
+		Filter:    binding(pick(cfg.Keys.Filter, preset.Filter), "filter"),
+		Toggle:    binding(pick(cfg.Keys.Toggle, preset.Toggle), "toggle"),
+		ToggleAll: binding(pick(cfg.Keys.ToggleAll, preset.ToggleAll), "select all"),
+	}, nil
+}
+
+// binding creates a key binding, accepting "space" as a readable name for the space bar
+func binding(keys []string, description string) key.Binding {
+	names := make([]string, len(keys))
+	bound := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k
+		if k == "space" {
+			k = " "
+		}
+		bound[i] = k
+	}
+	return key.NewBinding(key.WithKeys(bound...), key.WithHelp(strings.Join(names, "/"), description))
+}
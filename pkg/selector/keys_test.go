@@ -0,0 +1,86 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		keys, err := NewKeyMap(config.SelectorConfig{})
+		require.NoError(t, err)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, keys.Down))
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, keys.Toggle))
+		assert.Equal(t, "space", keys.Toggle.Help().Key)
+	})
+
+	t.Run("emacs preset", func(t *testing.T) {
+		keys, err := NewKeyMap(config.SelectorConfig{Keymap: config.KeymapEmacs})
+		require.NoError(t, err)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlN}, keys.Down))
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyCtrlG}, keys.Quit))
+		assert.False(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}, keys.Quit))
+	})
+
+	t.Run("overrides replace the preset binding", func(t *testing.T) {
+		keys, err := NewKeyMap(config.SelectorConfig{
+			Keymap: config.KeymapVim,
+			Keys:   config.SelectorKeys{Toggle: []string{"tab"}},
+		})
+		require.NoError(t, err)
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyTab}, keys.Toggle))
+		assert.False(t, key.Matches(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, keys.Toggle))
+		assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyEsc}, keys.Quit))
+	})
+
+	t.Run("unknown keymap", func(t *testing.T) {
+		_, err := NewKeyMap(config.SelectorConfig{Keymap: "nano"})
+		assert.ErrorContains(t, err, "unknown selector keymap")
+	})
+}
+
+func TestModel_CustomKeyMap(t *testing.T) {
+	keys, err := NewKeyMap(config.SelectorConfig{Keymap: config.KeymapEmacs})
+	require.NoError(t, err)
+
+	items := []Item{
+		{Path: "/repo/one", Name: "one"},
+		{Path: "/repo/two", Name: "two"},
+	}
+	var m tea.Model = newModel("Test", items, TUI{Keys: &keys, Width: defaultWidth}, false)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, "/repo/two", m.(model).choice)
+
+	m = newModel("Test", items, TUI{Keys: &keys, Width: defaultWidth}, false)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	assert.False(t, m.(model).quitting, "q is not bound to quit in the emacs preset")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	assert.True(t, m.(model).quitting)
+}
+
+func TestNewModel_Height(t *testing.T) {
+	items := []Item{{Path: "/repo/one", Name: "one"}}
+	m := newModel("Test", items, TUI{Height: 8, Width: defaultWidth}, false)
+	assert.Equal(t, 8, m.list.Height())
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Prompt is a selector backend that prints a numbered list and reads the answer from a line of input.
+// It works without a TTY, e.g. over dumb SSH sessions or when input is piped.
+type Prompt struct {
+	In  *bufio.Reader
+	Out io.Writer
+	// Status fills in Item.Dirty before the worktrees are listed when set
+	Status StatusFunc
+}
+
+// NewPrompt returns a prompt reading from stdin and writing to stderr so stdout stays clean
+func NewPrompt() Prompt {
+	return Prompt{In: bufio.NewReader(os.Stdin), Out: os.Stderr}
+}
+
+// withStatus returns items with Item.Dirty filled in by p.Status, or items unchanged when it is not set
+func (p Prompt) withStatus(items []Item) []Item {
+	if p.Status == nil {
+		return items
+	}
+	resolved := make([]Item, len(items))
+	for i, item := range items {
+		item.Dirty = p.Status(item.Path)
+		resolved[i] = item
+	}
+	return resolved
+}
+
+func (p Prompt) printItems(title string, items []Item) {
+	_, _ = fmt.Fprintln(p.Out, title)
+	for i, item := range p.withStatus(items) {
+		_, _ = fmt.Fprintf(p.Out, "  %d) %s\n", i+1, item.Label())
+	}
+}
+
+func (p Prompt) readLine() (string, error) {
+	line, err := p.In.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read selection: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Select returns the path of the chosen worktree or empty string if the answer is empty
+func (p Prompt) Select(title string, items []Item) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("no options provided")
+	}
+
+	p.printItems(title, items)
+	_, _ = fmt.Fprintf(p.Out, "Enter a number [1-%d] (empty to cancel): ", len(items))
+
+	answer, err := p.readLine()
+	if err != nil || answer == "" {
+		return "", err
+	}
+
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(items) {
+		return "", fmt.Errorf("invalid selection: %s", answer)
+	}
+	return items[n-1].Path, nil
+}
+
+// SelectMany returns the paths of the chosen worktrees. The answer is a list of numbers
+// and ranges separated by spaces or commas (e.g. "1 3-5"), or "a" for all of them.
+func (p Prompt) SelectMany(title string, items []Item) ([]string, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no options provided")
+	}
+
+	p.printItems(title, items)
+	_, _ = fmt.Fprintf(p.Out, "Enter numbers or ranges, e.g. 1 3-4, or a for all (empty to cancel): ")
+
+	answer, err := p.readLine()
+	if err != nil || answer == "" {
+		return nil, err
+	}
+
+	indexes, err := parseSelection(answer, len(items))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		paths = append(paths, items[i].Path)
+	}
+	return paths, nil
+}
+
+// Confirm lists lines under title and reports whether the user answered yes
+func (p Prompt) Confirm(title string, lines []string) (bool, error) {
+	_, _ = fmt.Fprintln(p.Out, title)
+	for _, line := range lines {
+		_, _ = fmt.Fprintf(p.Out, "  - %s\n", line)
+	}
+	_, _ = fmt.Fprint(p.Out, "Proceed? [y/N]: ")
+
+	answer, err := p.readLine()
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// parseSelection converts an answer such as "1, 3-4" into sorted, de-duplicated zero-based indexes
+func parseSelection(answer string, count int) ([]int, error) {
+	if strings.EqualFold(answer, "a") || strings.EqualFold(answer, "all") {
+		indexes := make([]int, count)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	chosen := make([]bool, count)
+	fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, field := range fields {
+		start, end := field, field
+		if before, after, found := strings.Cut(field, "-"); found {
+			start, end = before, after
+		}
+
+		from, err := strconv.Atoi(start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection: %s", field)
+		}
+		to, err := strconv.Atoi(end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection: %s", field)
+		}
+		if from < 1 || to > count || from > to {
+			return nil, fmt.Errorf("selection out of range: %s", field)
+		}
+
+		for n := from; n <= to; n++ {
+			chosen[n-1] = true
+		}
+	}
+
+	var indexes []int
+	for i, ok := range chosen {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
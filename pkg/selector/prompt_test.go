@@ -0,0 +1,128 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promptItems = []Item{
+	{Path: "/repo/one", Name: "one", Branch: "one"},
+	{Path: "/repo/two", Name: "two", Branch: "feature/two", Dirty: true},
+	{Path: "/repo/three", Name: "three", Branch: "three"},
+}
+
+func newTestPrompt(input string) (Prompt, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return Prompt{In: bufio.NewReader(strings.NewReader(input)), Out: out}, out
+}
+
+func TestPrompt_Select(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid number", input: "2\n", want: "/repo/two"},
+		{name: "no trailing newline", input: "3", want: "/repo/three"},
+		{name: "empty cancels", input: "\n", want: ""},
+		{name: "eof cancels", input: "", want: ""},
+		{name: "out of range", input: "4\n", wantErr: true},
+		{name: "not a number", input: "two\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, out := newTestPrompt(tt.input)
+			got, err := p.Select("Pick one:", promptItems)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, out.String(), "2) two [feature/two] (modified)")
+		})
+	}
+}
+
+func TestPrompt_SelectMany(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "single", input: "1\n", want: []string{"/repo/one"}},
+		{name: "list and range", input: "3, 1-2\n", want: []string{"/repo/one", "/repo/two", "/repo/three"}},
+		{name: "duplicates collapse", input: "2 2\n", want: []string{"/repo/two"}},
+		{name: "all", input: "a\n", want: []string{"/repo/one", "/repo/two", "/repo/three"}},
+		{name: "empty cancels", input: "\n", want: nil},
+		{name: "reversed range", input: "3-1\n", wantErr: true},
+		{name: "out of range", input: "0\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPrompt(tt.input)
+			got, err := p.SelectMany("Pick some:", promptItems)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrompt_Status(t *testing.T) {
+	p, out := newTestPrompt("1\n")
+	p.Status = func(path string) bool { return path == "/repo/three" }
+
+	_, err := p.Select("Pick one:", promptItems)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "2) two [feature/two]\n")
+	assert.Contains(t, out.String(), "3) three (modified)")
+}
+
+func TestPrompt_Confirm(t *testing.T) {
+	for input, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		p, out := newTestPrompt(input)
+		got, err := p.Confirm("Delete these?", []string{"one (branch one)"})
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "input %q", input)
+		assert.Contains(t, out.String(), "- one (branch one)")
+	}
+}
+
+func TestPrompt_SelectionFlowsAcrossCalls(t *testing.T) {
+	// A single prompt must be reused so buffered input is not lost between questions
+	p, _ := newTestPrompt("1 2\ny\n")
+	paths, err := p.SelectMany("Pick some:", promptItems)
+	require.NoError(t, err)
+	assert.Len(t, paths, 2)
+
+	confirmed, err := p.Confirm("Delete these?", nil)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+}
@@ -16,130 +16,109 @@ package selector
 
 import (
 	"fmt"
-	"io"
-	"strings"
+	"os"
 
-	"github.com/charmbracelet/bubbles/list"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/mattn/go-isatty"
 )
 
-var (
-	titleStyle        = lipgloss.NewStyle().MarginLeft(2)
-	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
-	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
-	helpStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
-	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
-)
-
-type item string
+// Selector lets the user choose worktrees interactively
+type Selector interface {
+	// Select returns the path of the chosen worktree or empty string if cancelled
+	Select(title string, items []Item) (string, error)
+	// SelectMany returns the paths of the chosen worktrees or nil if cancelled
+	SelectMany(title string, items []Item) ([]string, error)
+	// Confirm lists lines under title and reports whether the user accepted
+	Confirm(title string, lines []string) (bool, error)
+}
 
-func (i item) FilterValue() string { return string(i) }
+// Item is a worktree that can be chosen in the selector
+type Item struct {
+	Path   string
+	Name   string
+	Branch string
+	Dirty  bool
+}
 
-type itemDelegate struct{}
+// FilterValue matches against both the worktree name and its branch
+func (i Item) FilterValue() string { return i.Name + " " + i.Branch }
 
-func (d itemDelegate) Height() int                             { return 1 }
-func (d itemDelegate) Spacing() int                            { return 0 }
-func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
-func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	i, ok := listItem.(item)
-	if !ok {
-		return
+// Label renders the item as plain text for backends without styling
+func (i Item) Label() string {
+	label := i.Name
+	if i.Branch != "" && i.Branch != i.Name {
+		label += " [" + i.Branch + "]"
 	}
-
-	str := fmt.Sprintf("%d. %s", index+1, i)
-
-	fn := itemStyle.Render
-	if index == m.Index() {
-		fn = func(s ...string) string {
-			return selectedItemStyle.Render("> " + strings.Join(s, " "))
-		}
+	if i.Dirty {
+		label += " (modified)"
 	}
-
-	_, _ = fmt.Fprint(w, fn(str))
-}
-
-type model struct {
-	list     list.Model
-	choice   string
-	quitting bool
+	return label
 }
 
-func (m model) Init() tea.Cmd {
-	return nil
+// PreviewFunc renders the preview pane content for the worktree at path
+type PreviewFunc func(path string) string
+
+// StatusFunc reports whether the worktree at path has uncommitted changes
+type StatusFunc func(path string) bool
+
+// Preview describes how a backend shows details of the worktrees
+type Preview struct {
+	// Func is used by in-process backends
+	Func PreviewFunc
+	// Command is a shell command used by external finders; {1} is replaced with the worktree path
+	Command string
+	// Status fills in Item.Dirty. The TUI loads it in the background so the list shows
+	// straight away, other backends before listing the worktrees.
+	Status StatusFunc
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
-		return m, nil
-
-	case tea.KeyMsg:
-		switch keypress := msg.String(); keypress {
-		case "ctrl+c", "q":
-			m.quitting = true
-			return m, tea.Quit
-
-		case "enter":
-			i, ok := m.list.SelectedItem().(item)
-			if ok {
-				m.choice = string(i)
-			}
-			return m, tea.Quit
+// New returns the selector for the configured backend. The auto backend uses the TUI
+// when attached to a terminal and falls back to the plain prompt otherwise.
+func New(cfg config.SelectorConfig, preview Preview) (Selector, error) {
+	backend := cfg.Backend
+	switch backend {
+	case "", config.SelectorAuto:
+		if !IsInteractive() {
+			return newPrompt(preview.Status), nil
 		}
+		return newTUI(cfg, preview)
+	case config.SelectorTUI:
+		return newTUI(cfg, preview)
+	case config.SelectorFZF, config.SelectorSK:
+		return Finder{Binary: string(backend), PreviewCommand: preview.Command, Prompt: newPrompt(preview.Status)}, nil
+	case config.SelectorPrompt:
+		return newPrompt(preview.Status), nil
+	default:
+		return nil, fmt.Errorf("unknown selector backend: %s", backend)
 	}
-
-	var cmd tea.Cmd
-	m.list, cmd = m.list.Update(msg)
-	return m, cmd
 }
 
-func (m model) View() string {
-	if m.choice != "" {
-		return quitTextStyle.Render(fmt.Sprintf("%s? Sounds good to me.", m.choice))
-	}
-	if m.quitting {
-		return quitTextStyle.Render("Not feeling like choosing? That's cool.")
-	}
-	return "\n" + m.list.View()
+func newPrompt(status StatusFunc) Prompt {
+	p := NewPrompt()
+	p.Status = status
+	return p
 }
 
-// Select presents a list of options for the user to choose from
-// Returns the selected option or empty string if cancelled
-func Select(title string, options []string) (string, error) {
-	if len(options) == 0 {
-		return "", fmt.Errorf("no options provided")
-	}
-
-	items := make([]list.Item, len(options))
-	for i, option := range options {
-		items[i] = item(option)
+func newTUI(cfg config.SelectorConfig, preview Preview) (Selector, error) {
+	theme, err := NewTheme(cfg)
+	if err != nil {
+		return nil, err
 	}
-
-	const defaultWidth = 20
-	const listHeight = 14
-
-	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
-	l.Title = title
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
-	l.Styles.Title = titleStyle
-	l.Styles.PaginationStyle = paginationStyle
-	l.Styles.HelpStyle = helpStyle
-
-	m := model{list: l}
-
-	p := tea.NewProgram(m)
-	finalModel, err := p.Run()
+	keys, err := NewKeyMap(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to run selector: %w", err)
+		return nil, err
 	}
+	return TUI{Preview: preview.Func, Status: preview.Status, Theme: &theme, Keys: &keys, Height: cfg.Height}, nil
+}
 
-	if m, ok := finalModel.(model); ok {
-		return m.choice, nil
+// IsInteractive reports whether stdin and stdout are attached to a terminal capable of running the TUI
+func IsInteractive() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
 	}
+	return isTerminal(os.Stdin.Fd()) && isTerminal(os.Stdout.Fd())
+}
 
-	return "", nil
+func isTerminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
 }
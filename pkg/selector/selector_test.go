@@ -17,27 +17,82 @@ package selector
 import (
 	"testing"
 
+	"github.com/liamawhite/worktree/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestSelect(t *testing.T) {
-	t.Run("empty options", func(t *testing.T) {
-		_, err := Select("Test", []string{})
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "no options provided")
-	})
+func TestNew(t *testing.T) {
+	preview := Preview{Command: "wt preview {1}"}
 
-	// Note: We can't easily test the interactive functionality in unit tests
-	// since it requires terminal interaction. The main validation is that
-	// the function handles empty options correctly and doesn't panic.
-	t.Run("valid options structure", func(t *testing.T) {
-		// This test just ensures the function can be called without panicking
-		// Actual selection would require terminal interaction
-		options := []string{"option1", "option2", "option3"}
+	tests := []struct {
+		name    string
+		cfg     config.SelectorConfig
+		check   func(t *testing.T, s Selector)
+		wantErr bool
+	}{
+		{
+			name:  "tui",
+			cfg:   config.SelectorConfig{Backend: config.SelectorTUI},
+			check: func(t *testing.T, s Selector) { assert.IsType(t, TUI{}, s) },
+		},
+		{
+			name: "fzf",
+			cfg:  config.SelectorConfig{Backend: config.SelectorFZF},
+			check: func(t *testing.T, s Selector) {
+				f, ok := s.(Finder)
+				require.True(t, ok)
+				assert.Equal(t, "fzf", f.Binary)
+				assert.Equal(t, "wt preview {1}", f.PreviewCommand)
+			},
+		},
+		{
+			name:  "sk",
+			cfg:   config.SelectorConfig{Backend: config.SelectorSK},
+			check: func(t *testing.T, s Selector) { assert.Equal(t, "sk", s.(Finder).Binary) },
+		},
+		{
+			name:  "prompt",
+			cfg:   config.SelectorConfig{Backend: config.SelectorPrompt},
+			check: func(t *testing.T, s Selector) { assert.IsType(t, Prompt{}, s) },
+		},
+		{
+			// Tests never run with a TTY on stdin, so auto falls back to the prompt
+			name:  "auto without a terminal",
+			cfg:   config.SelectorConfig{Backend: config.SelectorAuto},
+			check: func(t *testing.T, s Selector) { assert.IsType(t, Prompt{}, s) },
+		},
+		{
+			name:    "unknown theme",
+			cfg:     config.SelectorConfig{Backend: config.SelectorTUI, Theme: "neon"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown keymap",
+			cfg:     config.SelectorConfig{Backend: config.SelectorTUI, Keymap: "nano"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown",
+			cfg:     config.SelectorConfig{Backend: config.SelectorBackend("dialog")},
+			wantErr: true,
+		},
+	}
 
-		// We can't run the actual interactive selection in tests,
-		// but we can verify the options are properly structured
-		assert.NotEmpty(t, options)
-		assert.Len(t, options, 3)
-	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg, preview)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, s)
+		})
+	}
+}
+
+func TestItem_Label(t *testing.T) {
+	assert.Equal(t, "main", Item{Name: "main", Branch: "main"}.Label())
+	assert.Equal(t, "login [feature/login] (modified)", Item{Name: "login", Branch: "feature/login", Dirty: true}.Label())
 }
@@ -0,0 +1,137 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/liamawhite/worktree/pkg/config"
+)
+
+const defaultPadding = 4
+
+// Theme holds the styles used by the TUI
+type Theme struct {
+	Title        lipgloss.Style
+	Item         lipgloss.Style
+	SelectedItem lipgloss.Style
+	Branch       lipgloss.Style
+	Clean        lipgloss.Style
+	Dirty        lipgloss.Style
+	Checked      lipgloss.Style
+	Preview      lipgloss.Style
+	Pagination   lipgloss.Style
+	Help         lipgloss.Style
+	Confirm      lipgloss.Style
+}
+
+type palette struct {
+	selected lipgloss.TerminalColor
+	branch   lipgloss.TerminalColor
+	clean    lipgloss.TerminalColor
+	dirty    lipgloss.TerminalColor
+	border   lipgloss.TerminalColor
+	// emphasise marks the highlighted item with bold and underline as well as color
+	emphasise bool
+}
+
+var palettes = map[string]palette{
+	config.ThemeDefault: {
+		selected: lipgloss.Color("170"),
+		branch:   lipgloss.Color("39"),
+		clean:    lipgloss.Color("42"),
+		dirty:    lipgloss.Color("214"),
+		border:   lipgloss.Color("240"),
+	},
+	config.ThemeHighContrast: {
+		selected:  lipgloss.Color("11"),
+		branch:    lipgloss.Color("14"),
+		clean:     lipgloss.Color("10"),
+		dirty:     lipgloss.Color("9"),
+		border:    lipgloss.Color("15"),
+		emphasise: true,
+	},
+	config.ThemeNone: {
+		selected:  lipgloss.NoColor{},
+		branch:    lipgloss.NoColor{},
+		clean:     lipgloss.NoColor{},
+		dirty:     lipgloss.NoColor{},
+		border:    lipgloss.NoColor{},
+		emphasise: true,
+	},
+}
+
+// DefaultTheme returns the theme used when nothing is configured
+func DefaultTheme() Theme {
+	theme, _ := NewTheme(config.SelectorConfig{})
+	return theme
+}
+
+// NewTheme builds the theme from the selector configuration. Colors are
+// disabled whenever the NO_COLOR environment variable is set (https://no-color.org).
+func NewTheme(cfg config.SelectorConfig) (Theme, error) {
+	name := cfg.Theme
+	if name == "" {
+		name = config.ThemeDefault
+	}
+	p, ok := palettes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown selector theme: %s (valid options: default, high-contrast, none)", name)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		p = palettes[config.ThemeNone]
+	} else {
+		override := func(c *lipgloss.TerminalColor, value string) {
+			if value != "" {
+				*c = lipgloss.Color(value)
+			}
+		}
+		override(&p.selected, cfg.Colors.Selected)
+		override(&p.branch, cfg.Colors.Branch)
+		override(&p.clean, cfg.Colors.Clean)
+		override(&p.dirty, cfg.Colors.Dirty)
+		override(&p.border, cfg.Colors.Border)
+	}
+
+	padding := defaultPadding
+	if cfg.Padding != nil {
+		padding = *cfg.Padding
+	}
+	// The highlighted item is prefixed with "> " so it stays aligned with the others
+	selectedPadding := max(padding-2, 0)
+
+	selected := lipgloss.NewStyle().PaddingLeft(selectedPadding).Foreground(p.selected)
+	if p.emphasise {
+		selected = selected.Bold(true).Underline(true)
+	}
+
+	return Theme{
+		Title:        lipgloss.NewStyle().MarginLeft(2),
+		Item:         lipgloss.NewStyle().PaddingLeft(padding),
+		SelectedItem: selected,
+		Branch:       lipgloss.NewStyle().Foreground(p.branch),
+		Clean:        lipgloss.NewStyle().Foreground(p.clean),
+		Dirty:        lipgloss.NewStyle().Foreground(p.dirty),
+		Checked:      lipgloss.NewStyle().Foreground(p.selected).Bold(p.emphasise),
+		Preview:      lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(p.border).Padding(0, 1),
+		Pagination:   list.DefaultStyles().PaginationStyle.PaddingLeft(padding),
+		Help:         list.DefaultStyles().HelpStyle.PaddingLeft(padding).PaddingBottom(1),
+		Confirm:      lipgloss.NewStyle().Margin(1, 0, 1, 2),
+	}, nil
+}
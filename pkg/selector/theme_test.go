@@ -0,0 +1,74 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	t.Run("default", func(t *testing.T) {
+		theme, err := NewTheme(config.SelectorConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("170"), theme.SelectedItem.GetForeground())
+		assert.Equal(t, lipgloss.Color("214"), theme.Dirty.GetForeground())
+		assert.False(t, theme.SelectedItem.GetBold())
+		assert.Equal(t, 4, theme.Item.GetPaddingLeft())
+	})
+
+	t.Run("high contrast emphasises the selection", func(t *testing.T) {
+		theme, err := NewTheme(config.SelectorConfig{Theme: config.ThemeHighContrast})
+		require.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("11"), theme.SelectedItem.GetForeground())
+		assert.True(t, theme.SelectedItem.GetBold())
+		assert.True(t, theme.SelectedItem.GetUnderline())
+	})
+
+	t.Run("color overrides and padding", func(t *testing.T) {
+		padding := 2
+		theme, err := NewTheme(config.SelectorConfig{
+			Colors:  config.SelectorColors{Selected: "#ff8800", Branch: "33"},
+			Padding: &padding,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, lipgloss.Color("#ff8800"), theme.SelectedItem.GetForeground())
+		assert.Equal(t, lipgloss.Color("33"), theme.Branch.GetForeground())
+		assert.Equal(t, lipgloss.Color("42"), theme.Clean.GetForeground())
+		assert.Equal(t, 2, theme.Item.GetPaddingLeft())
+		assert.Equal(t, 0, theme.SelectedItem.GetPaddingLeft())
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		_, err := NewTheme(config.SelectorConfig{Theme: "neon"})
+		assert.ErrorContains(t, err, "unknown selector theme")
+	})
+}
+
+func TestNewTheme_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	theme, err := NewTheme(config.SelectorConfig{Colors: config.SelectorColors{Selected: "170"}})
+	require.NoError(t, err)
+	assert.Equal(t, lipgloss.NoColor{}, theme.SelectedItem.GetForeground())
+	assert.Equal(t, lipgloss.NoColor{}, theme.Dirty.GetForeground())
+	assert.True(t, theme.SelectedItem.GetBold(), "the selection must remain visible without color")
+}
@@ -0,0 +1,502 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+type previewMsg struct {
+	path    string
+	content string
+}
+
+// statusMsg carries the dirty state of the item at index, loaded in the background
+type statusMsg struct {
+	index int
+	path  string
+	dirty bool
+}
+
+type itemDelegate struct {
+	theme Theme
+	// checked is shared with the model so toggles are visible when rendering; nil in single-select mode
+	checked map[string]bool
+	// dirty is shared with the model as statuses are loaded; nil when the items carry their own
+	dirty map[string]bool
+}
+
+func (d itemDelegate) Height() int                             { return 1 }
+func (d itemDelegate) Spacing() int                            { return 0 }
+func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(Item)
+	if !ok {
+		return
+	}
+
+	str := i.Name
+	if d.checked != nil {
+		if d.checked[i.Path] {
+			str = d.theme.Checked.Render("[x]") + " " + str
+		} else {
+			str = "[ ] " + str
+		}
+	}
+	if i.Branch != "" && i.Branch != i.Name {
+		str += " " + d.theme.Branch.Render("["+i.Branch+"]")
+	}
+	dirty, known := i.Dirty, true
+	if d.dirty != nil {
+		dirty, known = d.dirty[i.Path]
+	}
+	switch {
+	case !known:
+		// The badge appears once the status has loaded
+	case dirty:
+		str += " " + d.theme.Dirty.Render("●")
+	default:
+		str += " " + d.theme.Clean.Render("✓")
+	}
+
+	fn := d.theme.Item.Render
+	if index == m.Index() {
+		fn = func(s ...string) string {
+			return d.theme.SelectedItem.Render("> " + strings.Join(s, " "))
+		}
+	}
+
+	_, _ = fmt.Fprint(w, fn(str))
+}
+
+type model struct {
+	list     list.Model
+	choice   string
+	choices  []string
+	quitting bool
+
+	// multi enables toggling several items with space and selecting all with a
+	multi   bool
+	checked map[string]bool
+
+	theme Theme
+	keys  KeyMap
+
+	width    int
+	preview  PreviewFunc
+	previews map[string]string
+	loading  map[string]bool
+
+	status StatusFunc
+	dirty  map[string]bool
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.loadPreview(), m.loadStatus(0))
+}
+
+// loadStatus requests the dirty state of the item at index in the background. Items are loaded one
+// at a time, in list order, so a long list doesn't start a git process per worktree at once.
+func (m model) loadStatus(index int) tea.Cmd {
+	items := m.list.Items()
+	if m.status == nil || index >= len(items) {
+		return nil
+	}
+	i, ok := items[index].(Item)
+	if !ok {
+		return m.loadStatus(index + 1)
+	}
+
+	status := m.status
+	return func() tea.Msg {
+		return statusMsg{index: index, path: i.Path, dirty: status(i.Path)}
+	}
+}
+
+// loadPreview requests the preview of the highlighted worktree in the background
+// so that moving through the list never blocks on git
+func (m model) loadPreview() tea.Cmd {
+	if m.preview == nil {
+		return nil
+	}
+	i, ok := m.list.SelectedItem().(Item)
+	if !ok {
+		return nil
+	}
+	if _, cached := m.previews[i.Path]; cached || m.loading[i.Path] {
+		return nil
+	}
+	m.loading[i.Path] = true
+
+	preview := m.preview
+	return func() tea.Msg {
+		return previewMsg{path: i.Path, content: preview(i.Path)}
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.list.SetWidth(m.listWidth())
+		return m, nil
+
+	case previewMsg:
+		delete(m.loading, msg.path)
+		m.previews[msg.path] = msg.content
+		return m, nil
+
+	case statusMsg:
+		m.dirty[msg.path] = msg.dirty
+		return m, m.loadStatus(msg.index + 1)
+
+	case tea.KeyMsg:
+		// Let the filter input consume keys while the user is typing
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		// Esc clears an applied filter first, even when it is also bound to quit
+		if m.list.FilterState() == list.FilterApplied && key.Matches(msg, m.list.KeyMap.ClearFilter) {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			m.quitting = true
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Choose):
+			i, ok := m.list.SelectedItem().(Item)
+			if ok {
+				m.choice = i.Path
+			}
+			if m.multi {
+				m.choices = m.checkedPaths()
+				if len(m.choices) == 0 && ok {
+					m.choices = []string{i.Path}
+				}
+			}
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Toggle):
+			if m.multi {
+				if i, ok := m.list.SelectedItem().(Item); ok {
+					m.checked[i.Path] = !m.checked[i.Path]
+				}
+				return m, nil
+			}
+
+		case key.Matches(msg, m.keys.ToggleAll):
+			if m.multi {
+				m.toggleAll()
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, tea.Batch(cmd, m.loadPreview())
+}
+
+// checkedPaths returns the toggled items in list order
+func (m model) checkedPaths() []string {
+	var paths []string
+	for _, listItem := range m.list.Items() {
+		if i, ok := listItem.(Item); ok && m.checked[i.Path] {
+			paths = append(paths, i.Path)
+		}
+	}
+	return paths
+}
+
+// toggleAll selects every item the filter shows, or clears them if they are all selected already.
+// Items hidden by the filter are left alone so nothing the user can't see gets selected.
+func (m model) toggleAll() {
+	items := m.list.VisibleItems()
+	all := true
+	for _, listItem := range items {
+		if i, ok := listItem.(Item); ok && !m.checked[i.Path] {
+			all = false
+		}
+	}
+	for _, listItem := range items {
+		if i, ok := listItem.(Item); ok {
+			m.checked[i.Path] = !all
+		}
+	}
+}
+
+// listWidth returns the width of the list column, leaving the rest for the preview pane
+func (m model) listWidth() int {
+	if m.preview == nil {
+		return m.width
+	}
+	return m.width * 2 / 5
+}
+
+func (m model) previewView() string {
+	i, ok := m.list.SelectedItem().(Item)
+	if !ok {
+		return ""
+	}
+
+	content, cached := m.previews[i.Path]
+	if !cached {
+		content = "Loading..."
+	}
+
+	// Account for the border and padding of the preview pane
+	width := m.width - m.listWidth() - 4
+	if width < 10 {
+		width = 10
+	}
+	height := m.list.Height() - 2
+
+	return m.theme.Preview.Width(width).MaxHeight(height + 2).Render(
+		lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(content),
+	)
+}
+
+func (m model) View() string {
+	if m.choice != "" || m.quitting {
+		return ""
+	}
+	if m.multi {
+		return "\n" + m.listAndPreviewView() + fmt.Sprintf("\n  %s: toggle • %s: select all • %s: confirm",
+			m.keys.Toggle.Help().Key, m.keys.ToggleAll.Help().Key, m.keys.Choose.Help().Key)
+	}
+	return "\n" + m.listAndPreviewView()
+}
+
+func (m model) listAndPreviewView() string {
+	if m.preview == nil {
+		return m.list.View()
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.list.View(), m.previewView())
+}
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 14
+)
+
+// terminalSize returns the size of the terminal on stdout, falling back to the defaults
+// when stdout is not a terminal. Bubble Tea only reports the size after the first render,
+// so sizing up front avoids the list jumping once it starts.
+func terminalSize() (int, int) {
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || width <= 0 || height <= 0 {
+		return defaultWidth, defaultHeight
+	}
+	return width, height
+}
+
+func newModel(title string, items []Item, t TUI, multi bool) model {
+	t = t.withDefaults()
+
+	listItems := make([]list.Item, len(items))
+	for i, it := range items {
+		listItems[i] = it
+	}
+
+	width, termHeight := terminalSize()
+	if t.Width > 0 {
+		width = t.Width
+	}
+	height := t.Height
+	if height <= 0 {
+		height = defaultHeight
+	}
+	// Leave room for the blank line above the list and the multi-select hint below it
+	height = max(min(height, termHeight-2), 4)
+
+	delegate := itemDelegate{theme: *t.Theme}
+	if multi {
+		delegate.checked = map[string]bool{}
+	}
+	if t.Status != nil {
+		delegate.dirty = map[string]bool{}
+	}
+
+	l := list.New(listItems, delegate, width, height)
+	l.Title = title
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = t.Theme.Title
+	l.Styles.PaginationStyle = t.Theme.Pagination
+	l.Styles.HelpStyle = t.Theme.Help
+	l.KeyMap.CursorUp = t.Keys.Up
+	l.KeyMap.CursorDown = t.Keys.Down
+	l.KeyMap.Filter = t.Keys.Filter
+	// Quitting is handled by the model so that the selection is reported as cancelled
+	l.KeyMap.Quit = key.NewBinding(key.WithKeys(t.Keys.Quit.Keys()...), key.WithHelp(t.Keys.Quit.Help().Key, "quit"))
+
+	m := model{
+		list:     l,
+		multi:    multi,
+		checked:  delegate.checked,
+		theme:    *t.Theme,
+		keys:     *t.Keys,
+		width:    width,
+		preview:  t.Preview,
+		previews: map[string]string{},
+		loading:  map[string]bool{},
+		status:   t.Status,
+		dirty:    delegate.dirty,
+	}
+	m.list.SetWidth(m.listWidth())
+	return m
+}
+
+func run(m model) (model, error) {
+	p := tea.NewProgram(m)
+	finalModel, err := p.Run()
+	if err != nil {
+		return model{}, fmt.Errorf("failed to run selector: %w", err)
+	}
+
+	if m, ok := finalModel.(model); ok {
+		return m, nil
+	}
+
+	return model{}, nil
+}
+
+// TUI is the Bubble Tea selector backend
+type TUI struct {
+	// Preview renders the preview pane; the pane is hidden when nil
+	Preview PreviewFunc
+	// Status loads the dirty badges in the background; Item.Dirty is shown when nil
+	Status StatusFunc
+	// Theme and Keys default to DefaultTheme and DefaultKeyMap when nil
+	Theme *Theme
+	Keys  *KeyMap
+	// Height is the number of lines used by the list, capped to the terminal height
+	Height int
+	// Width overrides the detected terminal width
+	Width int
+}
+
+// withDefaults fills in the theme and key map when they have not been set
+func (t TUI) withDefaults() TUI {
+	if t.Theme == nil {
+		theme := DefaultTheme()
+		t.Theme = &theme
+	}
+	if t.Keys == nil {
+		keys := DefaultKeyMap()
+		t.Keys = &keys
+	}
+	return t
+}
+
+// Select presents a filterable list of worktrees for the user to choose from,
+// with an optional preview pane for the highlighted worktree.
+// Returns the path of the selected worktree or empty string if cancelled
+func (t TUI) Select(title string, items []Item) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("no options provided")
+	}
+
+	m, err := run(newModel(title, items, t, false))
+	if err != nil {
+		return "", err
+	}
+	return m.choice, nil
+}
+
+// SelectMany presents a filterable list of worktrees where several can be toggled with space,
+// or all of them with a. If nothing is toggled the highlighted worktree is chosen.
+// Returns the paths of the selected worktrees or nil if cancelled
+func (t TUI) SelectMany(title string, items []Item) ([]string, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no options provided")
+	}
+
+	m, err := run(newModel(title, items, t, true))
+	if err != nil {
+		return nil, err
+	}
+	if m.quitting {
+		return nil, nil
+	}
+	return m.choices, nil
+}
+
+type confirmModel struct {
+	theme     Theme
+	title     string
+	lines     []string
+	confirmed bool
+	done      bool
+}
+
+func (m confirmModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m confirmModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y":
+			m.confirmed = true
+			m.done = true
+			return m, tea.Quit
+		case "n", "N", "q", "esc", "ctrl+c", "enter":
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m confirmModel) View() string {
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(m.theme.Title.Render(m.title) + "\n\n")
+	for _, line := range m.lines {
+		b.WriteString("  • " + line + "\n")
+	}
+	b.WriteString("\nProceed? [y/N]")
+	return m.theme.Confirm.Render(b.String())
+}
+
+// Confirm shows a confirmation screen listing lines and reports whether the user accepted
+func (t TUI) Confirm(title string, lines []string) (bool, error) {
+	p := tea.NewProgram(confirmModel{theme: *t.withDefaults().Theme, title: title, lines: lines})
+	finalModel, err := p.Run()
+	if err != nil {
+		return false, fmt.Errorf("failed to run confirmation: %w", err)
+	}
+
+	if m, ok := finalModel.(confirmModel); ok {
+		return m.confirmed, nil
+	}
+
+	return false, nil
+}
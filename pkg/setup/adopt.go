@@ -0,0 +1,306 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/worktree"
+)
+
+// adoptStagingDir holds the working files while the checkout is turned into a worktree
+const adoptStagingDir = ".wt-adopt"
+
+// AdoptOptions configures how an existing clone is converted
+type AdoptOptions struct {
+	// AllBranches also creates worktrees for every other local branch
+	AllBranches bool
+	// Out receives progress output. Defaults to os.Stdout.
+	Out io.Writer
+}
+
+// inProgressMarkers are files in .git that indicate an operation that must be finished before adopting
+var inProgressMarkers = []string{"MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD", "BISECT_LOG", "rebase-merge", "rebase-apply"}
+
+// Adopt converts the standard clone containing dir in place into the .bare layout used by setup.
+// Refs, remotes, config and stashes are kept in the repository, and the current checkout,
+// including uncommitted changes, becomes the worktree of its branch. If the conversion fails the clone
+// is restored. Returns the path of that worktree.
+func Adopt(dir string, opts AdoptOptions) (string, error) {
+	root, err := git.RunGitCommandOutputInDir(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+	root = filepath.Clean(root)
+
+	gitDir := filepath.Join(root, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not a standard clone: .git is not a directory", root)
+	}
+	for _, name := range []string{".bare", ".hooks", adoptStagingDir} {
+		if _, err := os.Lstat(filepath.Join(root, name)); err == nil {
+			return "", fmt.Errorf("cannot adopt %s: %s already exists", root, name)
+		}
+	}
+	// Submodule checkouts point at .git/modules, which the conversion would break
+	for _, name := range []string{".gitmodules", filepath.Join(".git", "modules")} {
+		if _, err := os.Lstat(filepath.Join(root, name)); err == nil {
+			return "", fmt.Errorf("cannot adopt %s: repositories with submodules are not supported", root)
+		}
+	}
+	for _, marker := range inProgressMarkers {
+		if _, err := os.Stat(filepath.Join(gitDir, marker)); err == nil {
+			return "", fmt.Errorf("cannot adopt %s: finish the operation in progress (%s) first", root, marker)
+		}
+	}
+
+	branch, err := git.RunGitCommandOutputInDir(root, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("cannot adopt %s: HEAD is detached, check out a branch first", root)
+	}
+
+	worktrees, err := git.ListWorktrees(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	if len(worktrees) > 1 {
+		return "", fmt.Errorf("cannot adopt %s: it already has linked worktrees, remove them first", root)
+	}
+
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	path, err := convert(out, root, branch)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.AllBranches {
+		if err := addBranchWorktrees(out, root, branch); err != nil {
+			return "", fmt.Errorf("adopted %s, but %w", root, err)
+		}
+	}
+	return path, nil
+}
+
+// convert turns the checkout of root into the worktree of branch. On failure everything
+// is put back so root is the standard clone it was before.
+func convert(out io.Writer, root, branch string) (path string, err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoreErr := restoreClone(out, root, branch); restoreErr != nil {
+			err = fmt.Errorf("%w (restoring the clone failed, working files may be in %s: %v)", err, filepath.Join(root, adoptStagingDir), restoreErr)
+			return
+		}
+		_, _ = fmt.Fprintln(out, "Adopt did not complete, the clone was restored")
+	}()
+
+	// Move the working files aside so the worktree directory can be registered empty
+	_, _ = fmt.Fprintln(out, "Moving working files aside")
+	staging := filepath.Join(root, adoptStagingDir)
+	if err := moveEntries(root, staging, ".git", adoptStagingDir); err != nil {
+		return "", fmt.Errorf("failed to move working files: %w", err)
+	}
+
+	_, _ = fmt.Fprintln(out, "Converting .git into a bare repository")
+	bareDir := filepath.Join(root, ".bare")
+	if err := os.Rename(filepath.Join(root, ".git"), bareDir); err != nil {
+		return "", err
+	}
+	if err := git.RunGitCommandInDirTo(out, bareDir, "config", "core.bare", "true"); err != nil {
+		return "", err
+	}
+	// A normal clone has no core.worktree, but unset it in case one was configured
+	_ = git.RunGitCommandInDirTo(out, bareDir, "config", "--unset", "core.worktree")
+	if err := writeGitDirFile(root); err != nil {
+		return "", err
+	}
+
+	worktreePath := filepath.Join(root, branch)
+	_, _ = fmt.Fprintf(out, "Creating worktree for %s from the existing checkout\n", branch)
+	if err := git.RunGitCommandInDirTo(out, root, "worktree", "add", "--no-checkout", worktreePath, branch); err != nil {
+		return "", fmt.Errorf("failed to register worktree for %s: %w", branch, err)
+	}
+
+	// Keep the index so staged changes survive and the files are not seen as modified
+	worktreeGitDir, err := git.RunGitCommandOutputInDir(worktreePath, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(filepath.Join(bareDir, "index"), filepath.Join(worktreeGitDir, "index")); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to move index: %w", err)
+	}
+	if err := moveEntries(staging, worktreePath); err != nil {
+		return "", fmt.Errorf("failed to move working files into %s: %w", worktreePath, err)
+	}
+	if err := os.Remove(staging); err != nil {
+		return "", err
+	}
+
+	// Record the base branch as setup does, so new worktrees don't fall back to HEAD
+	remote := baseRemote(root)
+	base := defaultBranch(root, remote, branch)
+	if err := git.RunGitCommandInDirTo(out, bareDir, "config", BaseBranchKey, base); err != nil {
+		return "", err
+	}
+
+	_, _ = fmt.Fprintln(out, "Creating worktree hooks")
+	wm := &worktree.WorktreeManager{GitRoot: root}
+	if err := wm.CreateHooks(remote, base); err != nil {
+		return "", err
+	}
+
+	return worktreePath, nil
+}
+
+// restoreClone undoes a partial conversion of root, putting .git and the working files back
+func restoreClone(out io.Writer, root, branch string) error {
+	staging := filepath.Join(root, adoptStagingDir)
+	bareDir := filepath.Join(root, ".bare")
+	worktreePath := filepath.Join(root, branch)
+
+	if err := os.RemoveAll(filepath.Join(root, ".hooks")); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(bareDir); err == nil {
+		// Files already moved into the worktree go back to the staging directory, and the index back to the repository
+		if _, err := os.Stat(filepath.Join(worktreePath, ".git")); err == nil {
+			worktreeGitDir, err := git.RunGitCommandOutputInDir(worktreePath, "rev-parse", "--absolute-git-dir")
+			if err != nil {
+				return err
+			}
+			if err := os.Rename(filepath.Join(worktreeGitDir, "index"), filepath.Join(bareDir, "index")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := moveEntries(worktreePath, staging, ".git"); err != nil {
+				return err
+			}
+			if err := os.Remove(filepath.Join(worktreePath, ".git")); err != nil {
+				return err
+			}
+		}
+		removeEmptyDirs(worktreePath, root)
+		_ = git.RunGitCommandInDirTo(out, bareDir, "worktree", "prune")
+
+		if info, err := os.Lstat(filepath.Join(root, ".git")); err == nil && !info.IsDir() {
+			if err := os.Remove(filepath.Join(root, ".git")); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(bareDir, filepath.Join(root, ".git")); err != nil {
+			return err
+		}
+		_ = git.RunGitCommandInDirTo(out, root, "config", "--unset", BaseBranchKey)
+		if bare, _ := git.RunGitCommandOutputInDir(root, "config", "core.bare"); bare == "true" {
+			if err := git.RunGitCommandInDirTo(out, root, "config", "core.bare", "false"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := os.Stat(staging); err == nil {
+		if err := moveEntries(staging, root); err != nil {
+			return err
+		}
+		return os.Remove(staging)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents up to, but not including, root while they are empty
+func removeEmptyDirs(dir, root string) {
+	for ; dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// defaultBranch returns the default branch of remote if it is known, otherwise current
+func defaultBranch(root, remote, current string) string {
+	ref, err := git.RunGitCommandOutputInDir(root, "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil || ref == "" {
+		return current
+	}
+	return strings.TrimPrefix(ref, remote+"/")
+}
+
+// addBranchWorktrees creates a worktree for every local branch except current
+func addBranchWorktrees(out io.Writer, root, current string) error {
+	output, err := git.RunGitCommandOutputInDir(root, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return err
+	}
+
+	for _, branch := range strings.Split(output, "\n") {
+		if branch == "" || branch == current {
+			continue
+		}
+		path := filepath.Join(root, branch)
+		if _, err := os.Stat(path); err == nil {
+			_, _ = fmt.Fprintf(out, "Warning: skipping branch %s, %s already exists\n", branch, path)
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "Creating worktree for %s\n", branch)
+		if err := git.RunGitCommandInDirTo(out, root, "worktree", "add", path, branch); err != nil {
+			return fmt.Errorf("failed to create worktree for %s: %w", branch, err)
+		}
+	}
+	return nil
+}
+
+// baseRemote returns the remote new branches are based on, matching setup: upstream for forks, otherwise origin
+func baseRemote(root string) string {
+	remotes, err := git.RunGitCommandOutputInDir(root, "remote")
+	if err == nil {
+		for _, remote := range strings.Split(remotes, "\n") {
+			if remote == "upstream" {
+				return "upstream"
+			}
+		}
+	}
+	return "origin"
+}
+
+// moveEntries moves every entry of src except the skipped names into dst, creating dst if needed
+func moveEntries(src, dst string, skip ...string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if slices.Contains(skip, entry.Name()) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
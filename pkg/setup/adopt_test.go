@@ -0,0 +1,159 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClone creates a standard clone with a second branch, a stash, a staged file and an unstaged change
+func newClone(t *testing.T) string {
+	t.Helper()
+	upstream := t.TempDir()
+	gittest.Run(t, upstream, "init", "--initial-branch=main")
+	require.NoError(t, os.WriteFile(filepath.Join(upstream, "README.md"), []byte("hello\n"), 0644))
+	gittest.Run(t, upstream, "add", ".")
+	gittest.Run(t, upstream, "commit", "-m", "initial commit")
+
+	clone := filepath.Join(t.TempDir(), "repo")
+	gittest.Run(t, filepath.Dir(clone), "clone", upstream, clone)
+	gittest.Run(t, clone, "branch", "feature/login")
+
+	require.NoError(t, os.WriteFile(filepath.Join(clone, "README.md"), []byte("stashed\n"), 0644))
+	gittest.Run(t, clone, "stash")
+
+	require.NoError(t, os.WriteFile(filepath.Join(clone, "staged.txt"), []byte("staged\n"), 0644))
+	gittest.Run(t, clone, "add", "staged.txt")
+	require.NoError(t, os.WriteFile(filepath.Join(clone, "README.md"), []byte("changed\n"), 0644))
+	return clone
+}
+
+func TestAdopt(t *testing.T) {
+	clone := newClone(t)
+	before := gittest.Run(t, clone, "status", "--short")
+
+	var out bytes.Buffer
+	path, err := Adopt(clone, AdoptOptions{Out: &out})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Moving working files aside")
+	assert.Contains(t, out.String(), "Preparing worktree")
+
+	root, err := filepath.EvalSymlinks(clone)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "main"), path)
+
+	assert.DirExists(t, filepath.Join(clone, ".bare"))
+	assert.NoDirExists(t, filepath.Join(clone, adoptStagingDir))
+	assert.FileExists(t, filepath.Join(clone, ".hooks", "post-add.sh"))
+	gitFile, err := os.ReadFile(filepath.Join(clone, ".git"))
+	require.NoError(t, err)
+	assert.Equal(t, "gitdir: "+root+"/.bare", string(gitFile))
+	assert.Equal(t, "true", gittest.Run(t, clone, "config", "core.bare"))
+
+	// The checkout keeps its staged and unstaged changes
+	assert.Equal(t, before, gittest.Run(t, path, "status", "--short"))
+	assert.Equal(t, "main", gittest.Run(t, path, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	// Refs, remotes and stashes are preserved
+	assert.Contains(t, gittest.Run(t, path, "stash", "list"), "stash@{0}")
+	assert.NotEmpty(t, gittest.Run(t, path, "remote", "get-url", "origin"))
+	assert.NotEmpty(t, gittest.Run(t, path, "rev-parse", "--verify", "refs/heads/feature/login"))
+	assert.NoDirExists(t, filepath.Join(clone, "feature"))
+
+	// The remote's default branch is recorded and used by the hooks
+	assert.Equal(t, "main", gittest.Run(t, clone, "config", BaseBranchKey))
+	hook, err := os.ReadFile(filepath.Join(clone, ".hooks", "post-add.sh"))
+	require.NoError(t, err)
+	assert.Contains(t, string(hook), "git pull origin main")
+}
+
+func TestAdopt_BaseBranchFromRemote(t *testing.T) {
+	clone := newClone(t)
+	gittest.Run(t, clone, "checkout", "feature/login")
+
+	path, err := Adopt(clone, AdoptOptions{Out: io.Discard})
+	require.NoError(t, err)
+	assert.Equal(t, "feature/login", gittest.Run(t, path, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "main", gittest.Run(t, path, "config", BaseBranchKey))
+}
+
+func TestAdopt_RestoresCloneOnFailure(t *testing.T) {
+	clone := newClone(t)
+	before := gittest.Run(t, clone, "status", "--short")
+
+	// A stale config lock makes the conversion fail after the files were moved and .git renamed
+	require.NoError(t, os.WriteFile(filepath.Join(clone, ".git", "config.lock"), nil, 0644))
+
+	_, err := Adopt(clone, AdoptOptions{Out: io.Discard})
+	require.Error(t, err)
+	require.NoError(t, os.Remove(filepath.Join(clone, ".git", "config.lock")))
+
+	assert.DirExists(t, filepath.Join(clone, ".git"))
+	assert.NoDirExists(t, filepath.Join(clone, ".bare"))
+	assert.NoDirExists(t, filepath.Join(clone, ".hooks"))
+	assert.NoDirExists(t, filepath.Join(clone, adoptStagingDir))
+	assert.FileExists(t, filepath.Join(clone, "README.md"))
+	assert.Equal(t, "false", gittest.Run(t, clone, "config", "core.bare"))
+	assert.Equal(t, before, gittest.Run(t, clone, "status", "--short"))
+}
+
+func TestAdopt_AllBranches(t *testing.T) {
+	clone := newClone(t)
+
+	_, err := Adopt(clone, AdoptOptions{AllBranches: true, Out: io.Discard})
+	require.NoError(t, err)
+
+	feature := filepath.Join(clone, "feature", "login")
+	assert.DirExists(t, feature)
+	assert.Equal(t, "feature/login", gittest.Run(t, feature, "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestAdopt_Refuses(t *testing.T) {
+	t.Run("already adopted", func(t *testing.T) {
+		clone := newClone(t)
+		_, err := Adopt(clone, AdoptOptions{Out: io.Discard})
+		require.NoError(t, err)
+
+		_, err = Adopt(filepath.Join(clone, "main"), AdoptOptions{Out: io.Discard})
+		assert.ErrorContains(t, err, "not a standard clone")
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		clone := newClone(t)
+		gittest.Run(t, clone, "checkout", "--detach")
+
+		_, err := Adopt(clone, AdoptOptions{Out: io.Discard})
+		assert.ErrorContains(t, err, "HEAD is detached")
+		assert.DirExists(t, filepath.Join(clone, ".git"))
+	})
+
+	t.Run("submodules", func(t *testing.T) {
+		clone := newClone(t)
+		require.NoError(t, os.WriteFile(filepath.Join(clone, ".gitmodules"), nil, 0644))
+
+		_, err := Adopt(clone, AdoptOptions{Out: io.Discard})
+		assert.ErrorContains(t, err, "submodules are not supported")
+		assert.DirExists(t, filepath.Join(clone, ".git"))
+		assert.FileExists(t, filepath.Join(clone, "README.md"))
+	})
+}
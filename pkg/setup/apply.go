@@ -0,0 +1,126 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/git"
+)
+
+// maxSearchDepth limits how many directories below a directory are searched for repositories
+const maxSearchDepth = 4
+
+// FindRepositories returns the roots of the repositories in the .bare layout at or below dir.
+// Hidden directories and the insides of repositories are not searched.
+func FindRepositories(dir string) ([]string, error) {
+	return findRoots(dir, false)
+}
+
+// FindClones is FindRepositories that also returns standard clones, whose .git is a directory
+func FindClones(dir string) ([]string, error) {
+	return findRoots(dir, true)
+}
+
+func findRoots(dir string, standard bool) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if isDir(filepath.Join(path, ".bare")) || (standard && isDir(filepath.Join(path, ".git"))) {
+			roots = append(roots, path)
+			return filepath.SkipDir
+		}
+		if rel, _ := filepath.Rel(dir, path); strings.Count(rel, string(filepath.Separator)) >= maxSearchDepth-1 {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return roots, err
+}
+
+// SourceURL returns the URL of the repository the clone with the given git directory was set up from.
+// Setup derives the fork from the upstream, so the upstream identifies the repository when there is one.
+func SourceURL(gitDir string) (string, error) {
+	for _, remote := range []string{"upstream", "origin"} {
+		if url, err := git.RunGitCommandOutput("--git-dir="+gitDir, "remote", "get-url", remote); err == nil {
+			return url, nil
+		}
+	}
+	return "", fmt.Errorf("no origin or upstream remote")
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ApplyConfig writes the commit identity and signing settings currently configured for the host of
+// the repository at root into its config, using the profile it was set up with or the one now matching
+// its org. Settings an earlier setup or apply wrote that are no longer configured are removed.
+// Returns the applied identity, which is empty for local repositories.
+func ApplyConfig(root string, cfg *config.Config) (git.CommitIdentity, error) {
+	bare := filepath.Join(root, ".bare")
+	if info, err := os.Stat(bare); err != nil || !info.IsDir() {
+		return git.CommitIdentity{}, fmt.Errorf("%s was not set up by wt: .bare is missing", root)
+	}
+
+	source, err := SourceURL(bare)
+	if err != nil {
+		return git.CommitIdentity{}, fmt.Errorf("%s has %w", root, err)
+	}
+	repoConfig, err := ParseRepoString(source, "main")
+	if err != nil {
+		return git.CommitIdentity{}, err
+	}
+	if repoConfig.Local {
+		return git.CommitIdentity{}, nil
+	}
+
+	recorded, _ := git.RunGitCommandOutput("--git-dir="+bare, "config", ProfileKey)
+	cfg, profile, err := cfg.ApplyProfile(repoConfig.Domain, repoConfig.Org, recorded)
+	if err != nil {
+		return git.CommitIdentity{}, err
+	}
+	// A profile added since setup is recorded so the credential helper uses it too
+	if profile != recorded {
+		if _, err := git.RunGitCommandOutput("--git-dir="+bare, "config", ProfileKey, profile); err != nil {
+			return git.CommitIdentity{}, fmt.Errorf("failed to record profile: %w", err)
+		}
+	}
+
+	id, err := commitIdentity(cfg, repoConfig.Domain)
+	if err != nil {
+		return git.CommitIdentity{}, err
+	}
+	return id, git.ConfigureCommitIdentity(bare, id)
+}
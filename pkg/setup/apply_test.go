@@ -0,0 +1,143 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRepositories(t *testing.T) {
+	dir := t.TempDir()
+	gittest.InitBareLayout(t, filepath.Join(dir, "api"), "https://github.com/acme/api.git")
+	gittest.InitBareLayout(t, filepath.Join(dir, "acme", "web"), "https://github.com/acme/web.git")
+	gittest.InitBareLayout(t, filepath.Join(dir, ".hidden", "repo"), "https://github.com/acme/repo.git")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api", "main", "nested", ".bare"), 0755))
+
+	roots, err := FindRepositories(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "acme", "web"), filepath.Join(dir, "api")}, roots)
+
+	roots, err = FindRepositories(filepath.Join(dir, "api"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "api")}, roots)
+}
+
+func TestFindClones(t *testing.T) {
+	dir := t.TempDir()
+	gittest.InitBareLayout(t, filepath.Join(dir, "api"), "https://github.com/acme/api.git")
+	clone := filepath.Join(dir, "acme", "web")
+	require.NoError(t, os.MkdirAll(clone, 0755))
+	gittest.Run(t, clone, "init")
+
+	roots, err := FindClones(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{clone, filepath.Join(dir, "api")}, roots)
+
+	roots, err = FindRepositories(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "api")}, roots)
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir := t.TempDir()
+	sign := true
+	cfg := config.DefaultConfig()
+	cfg.SetHostConfig("github.com", config.HostConfig{UserName: "Jane Doe", UserEmail: "jane@example.com"})
+	cfg.SetProfile("github.com", config.Profile{Name: "work", Orgs: []string{"acme"}, HostConfig: config.HostConfig{
+		UserEmail:     "jane@acme.com",
+		SigningKey:    "/keys/id_acme.pub",
+		SigningFormat: config.SigningFormatSSH,
+		SignCommits:   &sign,
+	}})
+
+	t.Run("profile matching the org", func(t *testing.T) {
+		root := filepath.Join(dir, "api")
+		bare := gittest.InitBareLayout(t, root, "git@github.com:acme/api.git")
+		gittest.Run(t, root, "--git-dir=.bare", "config", "user.email", "old@acme.com")
+
+		id, err := ApplyConfig(root, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", id.Email)
+		assert.Equal(t, "Jane Doe", gittest.Run(t, root, "--git-dir="+bare, "config", "user.name"))
+		assert.Equal(t, "jane@acme.com", gittest.Run(t, root, "--git-dir="+bare, "config", "user.email"))
+		assert.Equal(t, "/keys/id_acme.pub", gittest.Run(t, root, "--git-dir="+bare, "config", "user.signingkey"))
+		assert.Equal(t, "ssh", gittest.Run(t, root, "--git-dir="+bare, "config", "gpg.format"))
+		assert.Equal(t, "true", gittest.Run(t, root, "--git-dir="+bare, "config", "commit.gpgsign"))
+		// The newly matching profile is recorded
+		assert.Equal(t, "work", gittest.Run(t, root, "--git-dir="+bare, "config", ProfileKey))
+	})
+
+	t.Run("recorded profile", func(t *testing.T) {
+		root := filepath.Join(dir, "fork")
+		bare := gittest.InitBareLayout(t, root, "git@github.com:jdoe/fork.git")
+		gittest.Run(t, root, "--git-dir=.bare", "config", ProfileKey, "work")
+
+		_, err := ApplyConfig(root, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", gittest.Run(t, root, "--git-dir="+bare, "config", "user.email"))
+	})
+
+	t.Run("upstream identifies the repository", func(t *testing.T) {
+		root := filepath.Join(dir, "web")
+		bare := gittest.InitBareLayout(t, root, "git@github.com:jdoe/web.git")
+		gittest.Run(t, root, "--git-dir=.bare", "remote", "add", "upstream", "git@github.com:acme/web.git")
+
+		_, err := ApplyConfig(root, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", gittest.Run(t, root, "--git-dir="+bare, "config", "user.email"))
+	})
+
+	t.Run("host settings", func(t *testing.T) {
+		root := filepath.Join(dir, "personal")
+		bare := gittest.InitBareLayout(t, root, "https://github.com/jdoe/personal.git")
+
+		_, err := ApplyConfig(root, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", gittest.Run(t, root, "--git-dir="+bare, "config", "user.email"))
+		profile, _ := git.RunGitCommandOutput("--git-dir="+bare, "config", ProfileKey)
+		assert.Empty(t, profile)
+	})
+
+	t.Run("settings removed from the config", func(t *testing.T) {
+		root := filepath.Join(dir, "removed")
+		bare := gittest.InitBareLayout(t, root, "git@github.com:acme/removed.git")
+		_, err := ApplyConfig(root, cfg)
+		require.NoError(t, err)
+
+		cfg := config.DefaultConfig()
+		cfg.SetHostConfig("github.com", config.HostConfig{UserName: "Jane Doe"})
+		cfg.SetProfile("github.com", config.Profile{Name: "work", Orgs: []string{"acme"}})
+		_, err = ApplyConfig(root, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Doe", gittest.Run(t, root, "--git-dir="+bare, "config", "user.name"))
+		for _, key := range []string{"user.email", "user.signingkey", "gpg.format", "commit.gpgsign"} {
+			value, err := git.RunGitCommandOutput("--git-dir="+bare, "config", "--local", key)
+			assert.Error(t, err, "%s is still set to %s", key, value)
+		}
+	})
+
+	t.Run("not set up by wt", func(t *testing.T) {
+		_, err := ApplyConfig(t.TempDir(), cfg)
+		assert.ErrorContains(t, err, ".bare is missing")
+	})
+}
@@ -0,0 +1,271 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/worktree"
+)
+
+// EjectOptions configures how the worktree layout is converted back into a plain clone
+type EjectOptions struct {
+	// KeepWorktrees reattaches the other worktrees to the new repository instead of removing them
+	KeepWorktrees bool
+	// Out receives progress output. Defaults to os.Stdout.
+	Out io.Writer
+}
+
+// Eject converts the .bare layout at root back into a standard clone whose .git directory lives in
+// the worktree at target. The other worktrees are removed after checking they are clean, or
+// reattached with KeepWorktrees. Branches are never deleted. If the conversion fails the layout
+// is restored.
+func Eject(root, target string, opts EjectOptions) (err error) {
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	bareDir := filepath.Join(root, ".bare")
+	if info, err := os.Stat(bareDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s does not use the worktree layout: .bare not found", root)
+	}
+
+	worktrees, err := git.ListWorktrees(root)
+	if err != nil {
+		return fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var targetWorktree *git.Worktree
+	var others []git.Worktree
+	for _, wt := range worktrees {
+		switch {
+		case wt.Bare:
+		case samePath(wt.Path, target):
+			targetWorktree = &wt
+		default:
+			others = append(others, wt)
+		}
+	}
+	if targetWorktree == nil {
+		return fmt.Errorf("worktree '%s' not found", filepath.Base(target))
+	}
+
+	// Check everything that can be checked before changing anything
+	gitFile := filepath.Join(target, ".git")
+	gitFileContent, err := os.ReadFile(gitFile)
+	if err != nil {
+		return fmt.Errorf("%s is not a linked worktree: %w", target, err)
+	}
+	worktreeGitDir, err := git.RunGitCommandOutputInDir(target, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return err
+	}
+	head, err := git.RunGitCommandOutput("--git-dir="+bareDir, "symbolic-ref", "--quiet", "HEAD")
+	if err != nil {
+		if head, err = git.RunGitCommandOutput("--git-dir="+bareDir, "rev-parse", "HEAD"); err != nil {
+			return fmt.Errorf("failed to read HEAD: %w", err)
+		}
+	}
+	if !opts.KeepWorktrees {
+		var dirty, locked []string
+		for _, wt := range others {
+			if status, err := git.StatusShort(wt.Path); err != nil || status != "" {
+				dirty = append(dirty, filepath.Base(wt.Path))
+			}
+			if wt.Locked {
+				locked = append(locked, filepath.Base(wt.Path))
+			}
+		}
+		if len(dirty) > 0 {
+			return fmt.Errorf("cannot remove worktrees with uncommitted changes: %s (commit or stash them, or use --keep-worktrees)", strings.Join(dirty, ", "))
+		}
+		if len(locked) > 0 {
+			return fmt.Errorf("cannot remove locked worktrees: %s (unlock them, or use --keep-worktrees)", strings.Join(locked, ", "))
+		}
+	}
+
+	// Each step that changes the layout registers how to undo it, so a failure restores the layout
+	var undo []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				err = fmt.Errorf("%w (restoring the worktree layout failed: %v)", err, undoErr)
+				return
+			}
+		}
+		_, _ = fmt.Fprintln(out, "Eject did not complete, the worktree layout was restored")
+	}()
+
+	if !opts.KeepWorktrees {
+		for _, wt := range others {
+			_, _ = fmt.Fprintf(out, "Removing worktree: %s\n", filepath.Base(wt.Path))
+			if err := git.RunGitCommandInDirTo(out, root, "worktree", "remove", wt.Path); err != nil {
+				return fmt.Errorf("failed to remove worktree %s: %w", filepath.Base(wt.Path), err)
+			}
+			// The worktree was clean, so checking its branch out again brings it back as it was
+			args := []string{"worktree", "add", wt.Path, wt.Branch}
+			if wt.Branch == "" {
+				args = []string{"worktree", "add", "--detach", wt.Path, wt.Head}
+			}
+			undo = append(undo, func() error { return git.RunGitCommandInDirTo(out, root, args...) })
+		}
+	}
+
+	// The clone moves out from under the object cache's records, so it must stop borrowing objects from
+	// it. The copied objects are harmless to the layout, so this isn't undone.
+	if err := dissociate(out, bareDir); err != nil {
+		return err
+	}
+
+	// Point HEAD of the repository at the target's checkout and take over its index
+	_, _ = fmt.Fprintf(out, "Moving the repository into %s\n", target)
+	if targetWorktree.Branch != "" {
+		err = git.RunGitCommandInDirTo(out, bareDir, "symbolic-ref", "HEAD", "refs/heads/"+targetWorktree.Branch)
+	} else {
+		err = git.RunGitCommandInDirTo(out, bareDir, "update-ref", "--no-deref", "HEAD", targetWorktree.Head)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	undo = append(undo, func() error {
+		if strings.HasPrefix(head, "refs/") {
+			return git.RunGitCommandInDirTo(out, bareDir, "symbolic-ref", "HEAD", head)
+		}
+		return git.RunGitCommandInDirTo(out, bareDir, "update-ref", "--no-deref", "HEAD", head)
+	})
+
+	if err := os.Rename(filepath.Join(worktreeGitDir, "index"), filepath.Join(bareDir, "index")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move index: %w", err)
+	}
+	undo = append(undo, func() error {
+		if err := os.Rename(filepath.Join(bareDir, "index"), filepath.Join(worktreeGitDir, "index")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+
+	// The worktree's own git directory is set aside until the end so it can be put back
+	aside, err := os.MkdirTemp(bareDir, "eject-")
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(worktreeGitDir, filepath.Join(aside, "gitdir")); err != nil {
+		return err
+	}
+	undo = append(undo, func() error {
+		if err := os.Rename(filepath.Join(aside, "gitdir"), worktreeGitDir); err != nil {
+			return err
+		}
+		return os.Remove(aside)
+	})
+
+	if err := os.Remove(gitFile); err != nil {
+		return err
+	}
+	undo = append(undo, func() error { return os.WriteFile(gitFile, gitFileContent, 0644) })
+
+	gitDir := filepath.Join(target, ".git")
+	if err := os.Rename(bareDir, gitDir); err != nil {
+		return err
+	}
+	undo = append(undo, func() error { return os.Rename(gitDir, bareDir) })
+
+	if err := git.RunGitCommandInDirTo(out, target, "config", "core.bare", "false"); err != nil {
+		return fmt.Errorf("failed to set core.bare: %w", err)
+	}
+	undo = append(undo, func() error { return git.RunGitCommandInDirTo(out, target, "config", "core.bare", "true") })
+
+	if opts.KeepWorktrees && len(others) > 0 {
+		_, _ = fmt.Fprintln(out, "Reattaching the other worktrees")
+		args := []string{"worktree", "repair"}
+		for _, wt := range others {
+			args = append(args, wt.Path)
+		}
+		// Repairing against .bare once everything else is restored points the worktrees back at it
+		undo = append([]func() error{func() error {
+			return git.RunGitCommandInDirTo(out, root, append([]string{"--git-dir=" + bareDir}, args...)...)
+		}}, undo...)
+		if err := git.RunGitCommandInDirTo(out, target, args...); err != nil {
+			return fmt.Errorf("failed to reattach worktrees: %w", err)
+		}
+	}
+
+	// The repository is in place, so nothing is undone from here. The set aside git directory moved
+	// into it with everything else.
+	undo = nil
+	if err := os.RemoveAll(filepath.Join(gitDir, filepath.Base(aside))); err != nil {
+		return err
+	}
+	return removeLayoutFiles(out, root)
+}
+
+// removeLayoutFiles removes the gitdir file and hooks created by setup, leaving anything the user added
+func removeLayoutFiles(out io.Writer, root string) error {
+	gitFile := filepath.Join(root, ".git")
+	if content, err := os.ReadFile(gitFile); err == nil {
+		if strings.HasPrefix(string(content), "gitdir:") && strings.HasSuffix(strings.TrimSpace(string(content)), ".bare") {
+			if err := os.Remove(gitFile); err != nil {
+				return err
+			}
+		} else {
+			_, _ = fmt.Fprintf(out, "Warning: leaving %s in place, it does not point at .bare\n", gitFile)
+		}
+	}
+
+	wm := &worktree.WorktreeManager{GitRoot: root}
+	if err := os.Remove(wm.GetPostAddHook()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(wm.GetHooksDir()); err != nil && !os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(out, "Warning: leaving %s in place, it contains other files\n", wm.GetHooksDir())
+	}
+	return nil
+}
+
+// samePath compares paths after resolving symlinks, since git reports worktree paths fully resolved
+func samePath(a, b string) bool {
+	resolve := func(p string) string {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return resolved
+		}
+		return filepath.Clean(p)
+	}
+	return resolve(a) == resolve(b)
+}
+
+// dissociate copies any objects the repository borrows through alternates into it and stops borrowing them
+func dissociate(out io.Writer, gitDir string) error {
+	alternates := filepath.Join(gitDir, "objects", "info", "alternates")
+	if _, err := os.Stat(alternates); err != nil {
+		return nil
+	}
+	_, _ = fmt.Fprintln(out, "Copying objects borrowed from the object cache")
+	if err := git.RunGitCommandInDirTo(out, gitDir, "--git-dir="+gitDir, "repack", "-a", "-d", "-q"); err != nil {
+		return fmt.Errorf("failed to copy borrowed objects: %w", err)
+	}
+	if err := os.Remove(alternates); err != nil {
+		return fmt.Errorf("failed to remove alternates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLayout adopts a clone so it has main and feature/login worktrees
+func newLayout(t *testing.T) string {
+	t.Helper()
+	clone := newClone(t)
+	_, err := Adopt(clone, AdoptOptions{AllBranches: true, Out: io.Discard})
+	require.NoError(t, err)
+	return clone
+}
+
+func TestEject(t *testing.T) {
+	root := newLayout(t)
+	target := filepath.Join(root, "main")
+	before := gittest.Run(t, target, "status", "--short")
+
+	require.NoError(t, Eject(root, target, EjectOptions{Out: io.Discard}))
+
+	assert.DirExists(t, filepath.Join(target, ".git"))
+	assert.NoDirExists(t, filepath.Join(root, ".bare"))
+	assert.NoFileExists(t, filepath.Join(root, ".git"))
+	assert.NoDirExists(t, filepath.Join(root, ".hooks"))
+	assert.NoDirExists(t, filepath.Join(root, "feature", "login"))
+
+	assert.Equal(t, "false", gittest.Run(t, target, "config", "core.bare"))
+	assert.Equal(t, before, gittest.Run(t, target, "status", "--short"))
+	assert.Equal(t, "main", gittest.Run(t, target, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Contains(t, gittest.Run(t, target, "stash", "list"), "stash@{0}")
+	assert.NotEmpty(t, gittest.Run(t, target, "rev-parse", "--verify", "refs/heads/feature/login"), "branches are kept")
+	assert.NotContains(t, gittest.Run(t, target, "worktree", "list"), "login")
+}
+
+func TestEject_KeepWorktrees(t *testing.T) {
+	root := newLayout(t)
+	target := filepath.Join(root, "feature", "login")
+	require.NoError(t, os.WriteFile(filepath.Join(target, "wip.txt"), []byte("wip\n"), 0644))
+
+	require.NoError(t, Eject(root, target, EjectOptions{KeepWorktrees: true, Out: io.Discard}))
+
+	assert.DirExists(t, filepath.Join(target, ".git"))
+	assert.Equal(t, "feature/login", gittest.Run(t, target, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "?? wip.txt", gittest.Run(t, target, "status", "--short"))
+
+	// The main worktree still works and points at the new repository
+	main := filepath.Join(root, "main")
+	assert.Equal(t, "main", gittest.Run(t, main, "rev-parse", "--abbrev-ref", "HEAD"))
+	commonDir := gittest.Run(t, main, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	assert.True(t, samePath(filepath.Join(target, ".git"), commonDir), commonDir)
+}
+
+func TestEject_RefusesDirtyWorktrees(t *testing.T) {
+	root := newLayout(t)
+	target := filepath.Join(root, "feature", "login")
+
+	// main carries the uncommitted changes from the original clone
+	err := Eject(root, target, EjectOptions{Out: io.Discard})
+	assert.ErrorContains(t, err, "uncommitted changes: main")
+	assert.DirExists(t, filepath.Join(root, ".bare"))
+	assert.DirExists(t, filepath.Join(root, "main"))
+}
+
+func TestEject_RestoresLayoutOnFailure(t *testing.T) {
+	root := newLayout(t)
+	target := filepath.Join(root, "main")
+	status := gittest.Run(t, target, "status", "--porcelain")
+
+	// A held config lock makes the last step fail, after the repository has moved
+	lock := filepath.Join(root, ".bare", "config.lock")
+	require.NoError(t, os.WriteFile(lock, nil, 0644))
+	err := Eject(root, target, EjectOptions{Out: io.Discard})
+	require.ErrorContains(t, err, "core.bare")
+	require.NoError(t, os.Remove(lock))
+
+	assert.DirExists(t, filepath.Join(root, ".bare"))
+	assert.FileExists(t, filepath.Join(target, ".git"))
+	assert.DirExists(t, filepath.Join(root, "feature", "login"))
+	assert.Equal(t, status, gittest.Run(t, target, "status", "--porcelain"))
+	gittest.Run(t, filepath.Join(root, "feature", "login"), "status")
+}
+
+func TestEject_UnknownWorktree(t *testing.T) {
+	root := newLayout(t)
+	err := Eject(root, filepath.Join(root, "missing"), EjectOptions{Out: io.Discard})
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestEject_DissociatesFromCache(t *testing.T) {
+	root := newLayout(t)
+	bare := filepath.Join(root, ".bare")
+
+	// Make the repository borrow every object from a copy, as a clone made with --reference would
+	objects := filepath.Join(t.TempDir(), "cache.git")
+	gittest.Run(t, root, "clone", "--bare", "--quiet", bare, objects)
+	require.NoError(t, os.WriteFile(filepath.Join(bare, "objects", "info", "alternates"), []byte(filepath.Join(objects, "objects")+"\n"), 0644))
+	gittest.Run(t, root, "--git-dir="+bare, "repack", "-a", "-d", "-l", "-q")
+
+	target := filepath.Join(root, "main")
+	require.NoError(t, Eject(root, target, EjectOptions{Out: io.Discard}))
+	require.NoError(t, os.RemoveAll(objects))
+
+	assert.NoFileExists(t, filepath.Join(target, ".git", "objects", "info", "alternates"))
+	gittest.Run(t, target, "fsck", "--no-dangling")
+}
@@ -16,198 +16,708 @@ package setup
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/liamawhite/worktree/pkg/cache"
 	"github.com/liamawhite/worktree/pkg/config"
 	"github.com/liamawhite/worktree/pkg/git"
 	"github.com/liamawhite/worktree/pkg/worktree"
+	"gopkg.in/yaml.v3"
 )
 
 type RepoConfig struct {
-	Domain   string
+	Domain string
+	// Org is the namespace the repository lives in. On GitLab this can be a group path of any depth, e.g. group/subgroup
 	Org      string
 	RepoName string
 	Branch   string
+	// Port is the SSH port given in an ssh:// URL or a manifest. It replaces the host's port in the URLs
+	// generated for the repository.
+	Port string
+	// URL is the clone URL given on the command line, empty when the shorthand form was used
+	URL string
+	// Local is set when URL is a filesystem path or file:// URL
+	Local bool
 }
 
+// ParseRepoString parses [domain/]namespace/repo shorthand as well as full clone URLs such as
+// https://github.com/org/repo.git, git@github.com:org/repo.git and ssh://git@host:7999/org/repo.git.
+// The namespace may contain several segments for GitLab subgroups. Local paths and file:// URLs
+// are accepted too and are cloned as-is.
 func ParseRepoString(repo, defaultBranch string) (*RepoConfig, error) {
-	parts := strings.Split(repo, "/")
-
 	config := &RepoConfig{
 		Branch: defaultBranch,
 	}
 
-	switch len(parts) {
-	case 3: // domain/org/repo (GHE or explicit github.com)
-		config.Domain = parts[0]
-		config.Org = parts[1]
-		config.RepoName = parts[2]
-	case 2: // org/repo (assume GitHub.com)
-		config.Domain = "github.com"
-		config.Org = parts[0]
-		config.RepoName = parts[1]
-	default:
-		return nil, fmt.Errorf("invalid repository format. Expected [domain/]org/repo")
+	source, name, isLocal, err := parseLocalSource(repo)
+	if err != nil {
+		return nil, err
+	}
+	if isLocal {
+		config.URL = source
+		config.RepoName = name
+		config.Local = true
+		return config, nil
+	}
+
+	u, isURL, err := parseRepoURL(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespace []string
+	if isURL {
+		parts := strings.Split(u.Path, "/")
+		if len(parts) < 2 || slices.Contains(parts, "") {
+			return nil, fmt.Errorf("invalid repository URL %s. Expected a path of namespace/repo", repo)
+		}
+		config.Domain = u.Host
+		config.Port = u.Port
+		config.URL = repo
+		namespace, config.RepoName = parts[:len(parts)-1], parts[len(parts)-1]
+	} else {
+		parts := strings.Split(strings.TrimSuffix(repo, ".git"), "/")
+		if slices.Contains(parts, "") {
+			return nil, fmt.Errorf("invalid repository format. Expected [domain/]org/repo or a clone URL")
+		}
+
+		switch {
+		case len(parts) >= 3: // domain/namespace/repo (GHE, GitLab or explicit github.com)
+			config.Domain = parts[0]
+			namespace, config.RepoName = parts[1:len(parts)-1], parts[len(parts)-1]
+		case len(parts) == 2: // org/repo (assume GitHub.com)
+			config.Domain = "github.com"
+			namespace, config.RepoName = parts[:1], parts[1]
+		default:
+			return nil, fmt.Errorf("invalid repository format. Expected [domain/]org/repo or a clone URL")
+		}
 	}
 
+	// GitHub has no nested namespaces, so a deeper path is almost certainly a typo
+	if config.Domain == "github.com" && len(namespace) > 1 {
+		return nil, fmt.Errorf("invalid repository %s. GitHub repositories are addressed as org/repo", repo)
+	}
+	config.Org = strings.Join(namespace, "/")
+
 	return config, nil
 }
 
+// UpstreamURL returns the URL of the original repository, preferring the URL given on the command line
+func (rc *RepoConfig) UpstreamURL(cfg *config.Config) (string, error) {
+	if rc.URL != "" {
+		return rc.URL, nil
+	}
+	return rc.hostConfig(cfg).GenerateRepositoryURL(rc.Domain, rc.Org, rc.RepoName)
+}
+
+// ForkURL returns the URL of the user's fork. Forks always live directly in the account's namespace,
+// which is how GitLab maps forks of projects in nested groups. When a URL was given on the command
+// line the fork is reached the same way, with the namespace replaced by the configured account,
+// unless the host has a fork URL template.
+func (rc *RepoConfig) ForkURL(cfg *config.Config) (string, error) {
+	if rc.URL != "" && !cfg.HasForkTemplate(rc.Domain) {
+		orgPath := rc.Org + "/" + rc.RepoName
+		if i := strings.LastIndex(rc.URL, orgPath); i >= 0 {
+			return rc.URL[:i] + cfg.GetAccount(rc.Domain) + "/" + rc.RepoName + rc.URL[i+len(orgPath):], nil
+		}
+	}
+	return rc.hostConfig(cfg).GenerateUserRepositoryURL(rc.Domain, rc.RepoName)
+}
+
+// hostConfig returns a copy of cfg in which the repository's port replaces the host's, so generated
+// URLs reach the same server as the URL it was given with. cfg is returned unchanged without a port.
+func (rc *RepoConfig) hostConfig(cfg *config.Config) *config.Config {
+	if rc.Port == "" {
+		return cfg
+	}
+	applied := *cfg
+	applied.Hosts = maps.Clone(cfg.Hosts)
+	host := cfg.GetHostConfig(rc.Domain)
+	host.Port = rc.Port
+	applied.SetHostConfig(rc.Domain, host)
+	return &applied
+}
+
 func (rc *RepoConfig) IsGitHubEnterprise() bool {
 	return rc.Domain != "github.com"
 }
 
-func SetupRepository(repoConfig *RepoConfig, configPath string) error {
-	if repoConfig.IsGitHubEnterprise() {
-		return setupGHERepo(repoConfig, configPath)
+// Options configures SetupRepository
+type Options struct {
+	// Dir is the directory the repository is created in. Defaults to the current directory.
+	Dir string
+	// Name is the local folder name of the repository. Defaults to the repository name.
+	Name string
+	// Remotes are configured in addition to the remotes setup creates
+	Remotes []Remote
+	// Worktrees are created after the base and review worktrees
+	Worktrees []Worktree
+	// Clone limits how much of the repository is cloned. A single-branch clone fetches the base branch.
+	Clone git.CloneOptions
+	// Cache is the shared object cache the clone borrows objects from. Local sources never use it.
+	Cache *cache.Cache
+	// CredentialHelper is configured in the clone as the git credential helper for the host when an
+	// access token is configured for it, so later fetches authenticate too. See config.HostConfig.TokenEnv.
+	CredentialHelper string
+	// Out receives progress output. Defaults to os.Stdout.
+	Out io.Writer
+	// ConfirmCleanup is asked whether to delete the directory created by a failed first-time setup.
+	// When nil, or when it returns false, the directory is kept so setup can be resumed by running it again.
+	ConfirmCleanup func(dir string) bool
+	// Profile names the host profile to use instead of the one matching the repository's namespace
+	Profile string
+	// ConfirmHostKey is asked whether to trust the key of an SSH host that isn't in known_hosts, when
+	// the host has no pinned fingerprint. When nil unknown hosts fail the clone.
+	ConfirmHostKey func(key git.HostKey) bool
+}
+
+// Root returns the absolute path of the repository root setup creates for repoConfig
+func (o Options) Root(repoConfig *RepoConfig) (string, error) {
+	name := o.Name
+	if name == "" {
+		name = repoConfig.RepoName
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid repository folder name %q", name)
+	}
+	return filepath.Abs(filepath.Join(o.Dir, name))
+}
+
+// Remote is a git remote configured by setup
+type Remote struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// Worktree is a worktree created by setup. Branches that don't exist locally are fetched from
+// their upstream when it has them, and created from the base branch otherwise.
+type Worktree struct {
+	// Name is the path of the worktree relative to the repository root. Defaults to Branch.
+	Name string `yaml:"name,omitempty"`
+	// Branch defaults to Name
+	Branch string `yaml:"branch,omitempty"`
+	// Upstream is the branch's upstream as <remote>/<branch>, e.g. origin/feature
+	Upstream string `yaml:"upstream,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain branch name as well as the full form
+func (w *Worktree) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*w = Worktree{Name: value.Value}
+		return nil
 	}
-	return setupGitHubRepo(repoConfig, configPath)
+	type plain Worktree
+	return value.Decode((*plain)(w))
 }
 
-func setupGHERepo(repoConfig *RepoConfig, configPath string) error {
-	// Load configuration to get account name for this domain
+// MarshalYAML writes worktrees named after their branch without an upstream as a plain branch name
+func (w Worktree) MarshalYAML() (any, error) {
+	if w.Upstream == "" && (w.Name == "" || w.Branch == "" || w.Branch == w.Name) {
+		return w.name(), nil
+	}
+	type plain Worktree
+	return plain(w), nil
+}
+
+func (w Worktree) name() string {
+	if w.Name == "" {
+		return w.Branch
+	}
+	return w.Name
+}
+
+func (w Worktree) branch() string {
+	if w.Branch == "" {
+		return w.Name
+	}
+	return w.Branch
+}
+
+// BaseBranchKey is the git config key setup records the base branch under
+const BaseBranchKey = worktree.BaseBranchKey
+
+// ProfileKey is the git config key setup records the host profile the repository was set up with under
+const ProfileKey = "wt.profile"
+
+// setupPlan describes the clone setup produces for a repository
+type setupPlan struct {
+	message  string
+	cloneURL string
+	// remotes are configured in addition to origin
+	remotes []Remote
+	// base is the remote new worktrees are based on
+	base string
+}
+
+// SetupRepository clones the repository into the .bare layout and returns the repository root.
+// It can be re-run safely: steps that are already complete are skipped, remotes pointing at the
+// wrong URL are repaired and missing worktrees are created, so an interrupted setup is resumed by
+// running it again. The process working directory is never changed.
+func SetupRepository(repoConfig *RepoConfig, configPath string, opts Options) (string, error) {
 	cfg, err := config.LoadConfigFromPath(configPath)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	root, err := opts.Root(repoConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var profile string
+	if !repoConfig.Local {
+		if cfg, profile, err = cfg.ApplyProfile(repoConfig.Domain, repoConfig.Org, opts.Profile); err != nil {
+			return "", err
+		}
+	}
+
+	plan, err := newSetupPlan(repoConfig, cfg)
+	if err != nil {
+		return "", err
+	}
+	if profile != "" {
+		plan.message = fmt.Sprintf("Using the %s profile for %s\n%s", profile, repoConfig.Domain, plan.message)
 	}
 
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	clone := opts.Clone
+	if clone.SingleBranch && clone.Branch == "" {
+		clone.Branch = repoConfig.Branch
+	}
+	if err := clone.Validate(); err != nil {
+		return "", err
+	}
+
+	// Worktrees are placed by the configured layout, as `wt add` places them
+	wm := &worktree.WorktreeManager{GitRoot: root, Layout: worktree.LayoutBare}
+	if wm.PathTemplate, err = cfg.WorktreePathTemplate(false); err != nil {
+		return "", err
+	}
+	if _, err := config.RenderWorktreePath(wm.PathTemplate, root, "branch"); err != nil {
+		return "", err
+	}
+
+	r := &runner{root: root, bare: filepath.Join(root, ".bare"), wm: wm, out: out, clone: clone, profile: profile}
+	if strings.HasPrefix(plan.cloneURL, "https://") || strings.HasPrefix(plan.cloneURL, "http://") {
+		username, token, err := cfg.GetToken(repoConfig.Domain)
+		if err != nil {
+			return "", err
+		}
+		if token != "" {
+			r.clone.Credentials = &git.Credentials{Username: username, Password: token}
+			r.credentialHelper = opts.CredentialHelper
+		}
+	} else if !repoConfig.Local {
+		identityFile, passphrase, err := cfg.GetSSHIdentity(repoConfig.Domain)
+		if err != nil {
+			return "", err
+		}
+		r.clone.SSH = git.SSHOptions{
+			IdentityFile:       identityFile,
+			Passphrase:         passphrase,
+			HostKeyFingerprint: cfg.GetHostConfig(repoConfig.Domain).HostKeyFingerprint,
+			ConfirmHostKey:     opts.ConfirmHostKey,
+		}
+	}
+	if !repoConfig.Local {
+		if r.identity, err = commitIdentity(cfg, repoConfig.Domain); err != nil {
+			return "", err
+		}
+	}
+	if opts.Cache != nil && !repoConfig.Local {
+		// Forks share most of their objects with the upstream, so the upstream is what gets cached
+		if r.cacheURL, err = repoConfig.UpstreamURL(cfg); err != nil {
+			return "", err
+		}
+		r.cache = opts.Cache
+		r.cachePath = opts.Cache.Path(repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
+	}
+	return root, r.run(repoConfig, plan, opts)
+}
+
+func newSetupPlan(repoConfig *RepoConfig, cfg *config.Config) (*setupPlan, error) {
+	if repoConfig.Local {
+		// There is no host to look up an account for, so there is no fork either
+		return directClonePlan(repoConfig, cfg)
+	}
+	if repoConfig.IsGitHubEnterprise() {
+		return gheRepoPlan(repoConfig, cfg)
+	}
+	return gitHubRepoPlan(repoConfig, cfg)
+}
+
+func gheRepoPlan(repoConfig *RepoConfig, cfg *config.Config) (*setupPlan, error) {
 	account := cfg.GetAccount(repoConfig.Domain)
 	if account == "" {
 		// For GHE, we'll clone directly from the original repository if no account is configured
-		fmt.Printf("No account configured for %s, cloning directly from %s/%s\n", repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
-		return setupDirectCloneGHE(repoConfig, configPath)
+		plan, err := directClonePlan(repoConfig, cfg)
+		if err != nil {
+			return nil, err
+		}
+		plan.message = fmt.Sprintf("No account configured for %s, cloning directly from %s/%s\n%s", repoConfig.Domain, repoConfig.Org, repoConfig.RepoName, plan.message)
+		return plan, nil
 	}
 
-	fmt.Printf("Cloning forked %s repository from %s and hiding .git internals\n", repoConfig.RepoName, repoConfig.Domain)
+	// Use the configured account for the fork, with an upstream remote pointing to the original repository
+	repoURL, err := repoConfig.ForkURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	upstreamURL, err := repoConfig.UpstreamURL(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := os.MkdirAll(repoConfig.RepoName, 0755); err != nil {
-		return err
+	return &setupPlan{
+		message:  fmt.Sprintf("Cloning forked %s repository from %s and hiding .git internals", repoConfig.RepoName, repoConfig.Domain),
+		cloneURL: repoURL,
+		remotes:  []Remote{{Name: "upstream", URL: upstreamURL}},
+		base:     "upstream",
+	}, nil
+}
+
+// directClonePlan clones directly from the original GHE or local repository
+func directClonePlan(repoConfig *RepoConfig, cfg *config.Config) (*setupPlan, error) {
+	source := fmt.Sprintf("%s/%s/%s", repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
+	if repoConfig.Local {
+		source = repoConfig.URL
 	}
 
-	if err := os.Chdir(repoConfig.RepoName); err != nil {
-		return err
+	repoURL, err := repoConfig.UpstreamURL(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Use the configured account for the fork
-	repoURL := cfg.GenerateUserRepositoryURL(repoConfig.Domain, repoConfig.RepoName)
-	if err := git.CloneBare(repoURL, ".bare"); err != nil {
-		return err
+	return &setupPlan{
+		message:  fmt.Sprintf("Cloning %s repository directly and hiding .git internals", source),
+		cloneURL: repoURL,
+		base:     "origin",
+	}, nil
+}
+
+func gitHubRepoPlan(repoConfig *RepoConfig, cfg *config.Config) (*setupPlan, error) {
+	account := cfg.GetAccount(repoConfig.Domain)
+	if account == "" {
+		return nil, fmt.Errorf("no account configured for %s. Use 'wt config set-account %s <username>' to configure", repoConfig.Domain, repoConfig.Domain)
 	}
 
-	if err := createGitDirFile(); err != nil {
-		return err
+	originURL, err := repoConfig.UpstreamURL(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add upstream remote pointing to the original repository
-	fmt.Println("Adding upstream remote")
-	upstreamURL := cfg.GenerateRepositoryURL(repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
-	if err := git.AddRemote(".bare", "upstream", upstreamURL); err != nil {
-		return err
+	plan := &setupPlan{
+		message:  fmt.Sprintf("Cloning %s repository and configuring remotes", repoConfig.RepoName),
+		cloneURL: originURL,
+		base:     "origin",
 	}
 
-	return finishSetup("upstream", repoConfig.Branch)
+	// If the account is different from the original org, add the fork as a remote
+	if account != repoConfig.Org {
+		forkURL, err := repoConfig.ForkURL(cfg)
+		if err != nil {
+			return nil, err
+		}
+		plan.remotes = append(plan.remotes, Remote{Name: account, URL: forkURL})
+	}
+
+	return plan, nil
 }
 
-// setupDirectCloneGHE clones directly from the original GHE repository
-func setupDirectCloneGHE(repoConfig *RepoConfig, configPath string) error {
-	fmt.Printf("Cloning %s/%s/%s repository directly and hiding .git internals\n", repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
+// runner performs the steps of a setup in root, reporting progress to out
+type runner struct {
+	root  string
+	bare  string
+	wm    *worktree.WorktreeManager
+	out   io.Writer
+	clone git.CloneOptions
+
+	cache     *cache.Cache
+	cachePath string
+	cacheURL  string
+
+	credentialHelper string
+	profile          string
+	identity         git.CommitIdentity
+}
 
-	cfg, err := config.LoadConfigFromPath(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+// run performs each step of the plan that isn't already done. If a first-time setup fails,
+// opts.ConfirmCleanup is offered the chance to remove everything it created.
+func (r *runner) run(repoConfig *RepoConfig, plan *setupPlan, opts Options) (err error) {
+	_, statErr := os.Stat(r.root)
+	fresh := os.IsNotExist(statErr)
+	if !fresh {
+		if err := checkResumable(r.root); err != nil {
+			return err
+		}
+		r.printf("Found existing %s, resuming setup\n", r.root)
 	}
 
-	if err := os.MkdirAll(repoConfig.RepoName, 0755); err != nil {
+	defer func() {
+		if err == nil || !fresh {
+			return
+		}
+		if opts.ConfirmCleanup == nil || !opts.ConfirmCleanup(r.root) {
+			r.printf("Setup did not complete, run the same command again to resume\n")
+			return
+		}
+		if rmErr := os.RemoveAll(r.root); rmErr != nil {
+			err = fmt.Errorf("%w (cleanup of %s failed: %v)", err, r.root, rmErr)
+			return
+		}
+		r.printf("Removed %s\n", r.root)
+	}()
+
+	r.printf("%s\n", plan.message)
+
+	if err := os.MkdirAll(r.root, 0755); err != nil {
 		return err
 	}
 
-	if err := os.Chdir(repoConfig.RepoName); err != nil {
+	if err := r.ensureClone(plan.cloneURL); err != nil {
 		return err
 	}
+	if r.cache != nil {
+		// Registering on every run covers a previous run that stopped between cloning and registering
+		if err := r.cache.Register(r.cachePath, r.bare); err != nil {
+			return fmt.Errorf("failed to register with the object cache: %w", err)
+		}
+	}
 
-	repoURL := cfg.GenerateRepositoryURL(repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
-	if err := git.CloneBare(repoURL, ".bare"); err != nil {
-		return err
+	if r.credentialHelper != "" {
+		if err := git.ConfigureCredentialHelper(r.bare, plan.cloneURL, r.credentialHelper); err != nil {
+			return fmt.Errorf("failed to configure credential helper: %w", err)
+		}
+	}
+
+	// The profile is recorded so the credential helper and later commands use the same identity
+	if r.profile != "" {
+		if err := git.RunGitCommandInDirTo(r.out, r.root, "--git-dir="+r.bare, "config", ProfileKey, r.profile); err != nil {
+			return fmt.Errorf("failed to record profile: %w", err)
+		}
+	}
+
+	if err := git.ConfigureCommitIdentity(r.bare, r.identity); err != nil {
+		return fmt.Errorf("failed to configure commit identity: %w", err)
+	}
+
+	// The host's identity file is recorded so fetches, pulls and pushes authenticate with it too
+	if err := git.ConfigureSSHCommand(r.bare, r.clone.SSH); err != nil {
+		return fmt.Errorf("failed to configure SSH identity: %w", err)
 	}
 
-	if err := createGitDirFile(); err != nil {
+	if err := writeGitDirFile(r.root); err != nil {
 		return err
 	}
 
-	return finishSetup("origin", repoConfig.Branch)
+	remotes := append([]Remote{{Name: "origin", URL: plan.cloneURL}}, plan.remotes...)
+	for _, remote := range append(remotes, opts.Remotes...) {
+		if err := r.ensureRemote(remote.Name, remote.URL); err != nil {
+			return err
+		}
+	}
+
+	return r.finish(plan.base, repoConfig.Branch, opts.Worktrees)
 }
 
-func setupGitHubRepo(repoConfig *RepoConfig, configPath string) error {
-	// Load configuration to get account name
-	cfg, err := config.LoadConfigFromPath(configPath)
+// commitIdentity returns the commit identity and signing settings configured for domain
+func commitIdentity(cfg *config.Config, domain string) (git.CommitIdentity, error) {
+	host := cfg.GetHostConfig(domain)
+	signingKey, err := cfg.GetSigningKey(domain)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return git.CommitIdentity{}, err
+	}
+	id := git.CommitIdentity{Name: host.UserName, Email: host.UserEmail, SigningKey: signingKey, Sign: host.SignCommits}
+	switch host.SigningFormat {
+	case config.SigningFormatGPG:
+		id.SigningFormat = "openpgp"
+	case config.SigningFormatSSH:
+		id.SigningFormat = "ssh"
 	}
+	return id, nil
+}
 
-	account := cfg.GetAccount(repoConfig.Domain)
-	if account == "" {
-		return fmt.Errorf("no account configured for %s. Use 'wt config set-account %s <username>' to configure", repoConfig.Domain, repoConfig.Domain)
+// checkResumable returns an error unless root is empty or was created by an earlier setup
+func checkResumable(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
 	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if info, err := os.Stat(filepath.Join(root, ".bare")); err == nil && info.IsDir() {
+		return nil
+	}
+	if info, err := os.Stat(filepath.Join(root, ".git")); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is already a clone, use 'wt adopt' to convert it or choose another --dir or --name", root)
+	}
+	return fmt.Errorf("%s already exists and was not created by setup, choose another --dir or --name", root)
+}
+
+func (r *runner) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(r.out, format, args...)
+}
 
-	fmt.Printf("Cloning %s repository and configuring remotes\n", repoConfig.RepoName)
+// ensureClone clones url into the bare repository unless a usable clone is already there. A clone
+// that was interrupted before any refs were fetched is discarded and retried.
+func (r *runner) ensureClone(url string) error {
+	if _, err := os.Stat(r.bare); err == nil {
+		// --git-dir stops git from falling back to a repository in a parent directory
+		if refs, err := git.RunGitCommandOutput("--git-dir="+r.bare, "for-each-ref", "--count=1"); err == nil && refs != "" {
+			r.printf("Repository already cloned\n")
+			return nil
+		}
+		r.printf("Removing incomplete clone\n")
+		if err := os.RemoveAll(r.bare); err != nil {
+			return err
+		}
+	}
 
-	if err := os.MkdirAll(repoConfig.RepoName, 0755); err != nil {
+	clone := r.clone
+	if r.cache != nil {
+		if err := r.cache.Ensure(r.out, r.cachePath, r.cacheURL); err != nil {
+			r.printf("Warning: not using the object cache: %v\n", err)
+		} else {
+			clone.Reference = r.cachePath
+		}
+	}
+	return git.CloneBareWithOptions(url, r.bare, r.out, clone)
+}
+
+// ensureRemote adds the remote, or points it at url if it exists with a different URL
+func (r *runner) ensureRemote(name, url string) error {
+	current, err := git.RunGitCommandOutput("--git-dir="+r.bare, "remote", "get-url", name)
+	if err != nil {
+		r.printf("Adding %s remote\n", name)
+		return git.AddRemote(r.bare, name, url)
+	}
+	if current != url {
+		r.printf("Updating %s remote from %s to %s\n", name, current, url)
+		return git.RunGitCommandInDirTo(r.out, r.root, "--git-dir="+r.bare, "remote", "set-url", name, url)
+	}
+	return nil
+}
+
+// writeGitDirFile points root/.git at the bare repository in root/.bare
+func writeGitDirFile(root string) error {
+	gitdirContent := fmt.Sprintf("gitdir: %s/.bare", root)
+	return os.WriteFile(filepath.Join(root, ".git"), []byte(gitdirContent), 0644)
+}
+
+func (r *runner) finish(base, branch string, worktrees []Worktree) error {
+	if err := git.RunGitCommandInDirTo(r.out, r.root, "--git-dir="+r.bare, "config", BaseBranchKey, branch); err != nil {
 		return err
 	}
 
-	if err := os.Chdir(repoConfig.RepoName); err != nil {
+	r.printf("Creating worktree hooks\n")
+	if err := r.wm.CreateHooks(base, branch); err != nil {
 		return err
 	}
 
-	// Clone from the original repository
-	originURL := cfg.GenerateRepositoryURL(repoConfig.Domain, repoConfig.Org, repoConfig.RepoName)
-	if err := git.CloneBare(originURL, ".bare"); err != nil {
+	// Forget worktrees whose directories were removed by an earlier failed run
+	if err := git.RunGitCommandInDirTo(r.out, r.root, "worktree", "prune"); err != nil {
 		return err
 	}
 
-	if err := createGitDirFile(); err != nil {
+	r.printf("Creating worktree for base branch %s\n", branch)
+	path := r.wm.WorktreePath(branch)
+	if err := r.ensureWorktree(branch, path, "worktree", "add", path, branch); err != nil {
 		return err
 	}
 
-	// If the account is different from the original org, add the fork as a remote
-	if account != repoConfig.Org {
-		fmt.Printf("Adding %s remote for your fork\n", account)
-		forkURL := cfg.GenerateUserRepositoryURL(repoConfig.Domain, repoConfig.RepoName)
-		if err := git.AddRemote(".bare", account, forkURL); err != nil {
+	r.printf("Creating worktree for a review branch\n")
+	path = r.wm.WorktreePath("review")
+	args := []string{"worktree", "add", "--force", "-b", "review", path}
+	if r.hasBranch("review") {
+		args = []string{"worktree", "add", "--force", path, "review"}
+	}
+	if err := r.ensureWorktree("review", path, args...); err != nil {
+		return err
+	}
+
+	for _, wt := range worktrees {
+		if wt.name() == branch || wt.name() == "review" {
+			continue
+		}
+		r.printf("Creating worktree for %s\n", wt.name())
+		path := r.wm.WorktreePath(wt.name())
+		if err := r.ensureWorktree(wt.name(), path, r.worktreeAddArgs(wt, path, branch)...); err != nil {
 			return err
 		}
+		if wt.Upstream != "" {
+			if err := r.setUpstream(wt.branch(), wt.Upstream); err != nil {
+				return err
+			}
+		}
 	}
 
-	return finishSetup("origin", repoConfig.Branch)
+	return nil
 }
 
-func createGitDirFile() error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
+// worktreeAddArgs returns the git arguments that create wt at path, checking out its branch when it
+// exists locally or can be fetched from its upstream, and creating it from base otherwise
+func (r *runner) worktreeAddArgs(wt Worktree, path, base string) []string {
+	branch := wt.branch()
+	if !r.hasBranch(branch) && wt.Upstream != "" {
+		remote, remoteBranch, _ := strings.Cut(wt.Upstream, "/")
+		// A branch that was never pushed can't be fetched, so failure falls back to creating it
+		args := append([]string{"--git-dir=" + r.bare, "fetch", "--progress"}, r.clone.FetchArgs()...)
+		_ = git.RunGitCommandInDirTo(r.out, r.root, append(args, remote, "refs/heads/"+remoteBranch+":refs/heads/"+branch)...)
+	}
+	if r.hasBranch(branch) {
+		return []string{"worktree", "add", path, branch}
 	}
+	return []string{"worktree", "add", "-b", branch, path, base}
+}
 
-	gitdirContent := fmt.Sprintf("gitdir: %s/.bare", cwd)
-	return os.WriteFile(".git", []byte(gitdirContent), 0644)
+func (r *runner) hasBranch(branch string) bool {
+	_, err := git.RunGitCommandOutput("--git-dir="+r.bare, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
 }
 
-func finishSetup(base, branch string) error {
-	fmt.Println("Creating worktree hooks")
-	wm := &worktree.WorktreeManager{GitRoot: "."}
-	if err := wm.CreateHooks(base, branch); err != nil {
+// setUpstream records upstream as the branch's upstream without requiring the remote-tracking ref to exist
+func (r *runner) setUpstream(branch, upstream string) error {
+	remote, remoteBranch, ok := strings.Cut(upstream, "/")
+	if !ok || remote == "" || remoteBranch == "" {
+		return fmt.Errorf("invalid upstream %q for %s, expected <remote>/<branch>", upstream, branch)
+	}
+	if err := git.RunGitCommandInDirTo(r.out, r.root, "--git-dir="+r.bare, "config", "branch."+branch+".remote", remote); err != nil {
 		return err
 	}
+	return git.RunGitCommandInDirTo(r.out, r.root, "--git-dir="+r.bare, "config", "branch."+branch+".merge", "refs/heads/"+remoteBranch)
+}
 
-	fmt.Printf("Creating worktree for base branch %s\n", branch)
-	if err := git.RunGitCommand("worktree", "add", branch); err != nil {
+// ensureWorktree runs the git command that creates the worktree named name at path unless it
+// already exists. An empty directory left behind by an interrupted run is replaced.
+func (r *runner) ensureWorktree(name, path string, args ...string) error {
+	worktrees, err := git.ListWorktrees(r.root)
+	if err != nil {
 		return err
 	}
+	for _, wt := range worktrees {
+		if !wt.Bare && samePath(wt.Path, path) {
+			r.printf("Worktree %s already exists\n", name)
+			return nil
+		}
+	}
 
-	fmt.Println("Creating worktree for a review branch")
-	if err := git.RunGitCommand("worktree", "add", "review", "--force"); err != nil {
-		return err
+	if entries, err := os.ReadDir(path); err == nil {
+		if len(entries) > 0 {
+			return fmt.Errorf("%s already exists and is not a worktree, move it out of the way and run setup again", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return git.RunGitCommandInDirTo(r.out, r.root, args...)
 }
@@ -15,10 +15,23 @@
 package setup
 
 import (
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/cache"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/git"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseRepoString(t *testing.T) {
@@ -53,6 +66,78 @@ func TestParseRepoString(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:          "shorthand with .git suffix",
+			repo:          "owner/repo.git",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "github.com",
+				Org:      "owner",
+				RepoName: "repo",
+				Branch:   "main",
+			},
+		},
+		{
+			name:          "HTTPS URL",
+			repo:          "https://github.com/owner/repo.git",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "github.com",
+				Org:      "owner",
+				RepoName: "repo",
+				Branch:   "main",
+				URL:      "https://github.com/owner/repo.git",
+			},
+		},
+		{
+			name:          "HTTPS URL without .git suffix",
+			repo:          "https://github.company.com/owner/repo/",
+			defaultBranch: "develop",
+			want: &RepoConfig{
+				Domain:   "github.company.com",
+				Org:      "owner",
+				RepoName: "repo",
+				Branch:   "develop",
+				URL:      "https://github.company.com/owner/repo/",
+			},
+		},
+		{
+			name:          "scp-style address",
+			repo:          "git@github.com:owner/repo.git",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "github.com",
+				Org:      "owner",
+				RepoName: "repo",
+				Branch:   "main",
+				URL:      "git@github.com:owner/repo.git",
+			},
+		},
+		{
+			name:          "SSH URL with port",
+			repo:          "ssh://git@git.company.com:7999/owner/repo.git",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "git.company.com",
+				Org:      "owner",
+				RepoName: "repo",
+				Branch:   "main",
+				Port:     "7999",
+				URL:      "ssh://git@git.company.com:7999/owner/repo.git",
+			},
+		},
+		{
+			name:          "unsupported URL scheme",
+			repo:          "ftp://github.com/owner/repo.git",
+			defaultBranch: "main",
+			wantErr:       true,
+		},
+		{
+			name:          "URL without org",
+			repo:          "https://github.com/repo.git",
+			defaultBranch: "main",
+			wantErr:       true,
+		},
 		{
 			name:          "invalid format - single part",
 			repo:          "justname",
@@ -61,8 +146,38 @@ func TestParseRepoString(t *testing.T) {
 			wantErr:       true,
 		},
 		{
-			name:          "invalid format - too many parts",
-			repo:          "a/b/c/d",
+			name:          "GitLab subgroup",
+			repo:          "gitlab.com/group/subgroup/team/repo",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "gitlab.com",
+				Org:      "group/subgroup/team",
+				RepoName: "repo",
+				Branch:   "main",
+			},
+		},
+		{
+			name:          "GitLab subgroup URL",
+			repo:          "git@gitlab.com:group/subgroup/repo.git",
+			defaultBranch: "main",
+			want: &RepoConfig{
+				Domain:   "gitlab.com",
+				Org:      "group/subgroup",
+				RepoName: "repo",
+				Branch:   "main",
+				URL:      "git@gitlab.com:group/subgroup/repo.git",
+			},
+		},
+		{
+			name:          "invalid format - nested namespace on github.com",
+			repo:          "github.com/a/b/c",
+			defaultBranch: "main",
+			want:          nil,
+			wantErr:       true,
+		},
+		{
+			name:          "invalid format - empty segment",
+			repo:          "gitlab.com/group//repo",
 			defaultBranch: "main",
 			want:          nil,
 			wantErr:       true,
@@ -83,6 +198,113 @@ func TestParseRepoString(t *testing.T) {
 	}
 }
 
+func TestRepoConfig_URLs(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SetAccount("github.com", "me")
+	cfg.SetCloneMethod("github.com", config.CloneMethodSSH)
+	cfg.SetAccount("git.company.com", "me")
+	cfg.SetAccount("gitlab.com", "me")
+	cfg.SetAccount("bitbucket.company.com", "me")
+	cfg.SetCloneMethod("bitbucket.company.com", config.CloneMethodSSH)
+	host := cfg.Hosts["bitbucket.company.com"]
+	host.URLs = map[config.CloneMethod]config.URLTemplates{
+		config.CloneMethodSSH: {Fork: "ssh://git@{{.Host}}:7999/scm/~{{.Account}}/{{.Repo}}.git"},
+	}
+	cfg.Hosts["bitbucket.company.com"] = host
+
+	tests := []struct {
+		name         string
+		repo         string
+		wantUpstream string
+		wantFork     string
+	}{
+		{
+			name:         "shorthand uses the configured clone method",
+			repo:         "owner/repo",
+			wantUpstream: "git@github.com:owner/repo.git",
+			wantFork:     "git@github.com:me/repo.git",
+		},
+		{
+			name:         "explicit HTTPS URL is kept",
+			repo:         "https://github.com/owner/repo.git",
+			wantUpstream: "https://github.com/owner/repo.git",
+			wantFork:     "https://github.com/me/repo.git",
+		},
+		{
+			name:         "explicit SSH URL keeps its port",
+			repo:         "ssh://git@git.company.com:7999/owner/repo.git",
+			wantUpstream: "ssh://git@git.company.com:7999/owner/repo.git",
+			wantFork:     "ssh://git@git.company.com:7999/me/repo.git",
+		},
+		{
+			name:         "GitLab forks of subgroup projects live in the account namespace",
+			repo:         "gitlab.com/group/subgroup/repo",
+			wantUpstream: "https://gitlab.com/group/subgroup/repo.git",
+			wantFork:     "https://gitlab.com/me/repo.git",
+		},
+		{
+			name:         "explicit GitLab subgroup URL",
+			repo:         "git@gitlab.com:group/subgroup/repo.git",
+			wantUpstream: "git@gitlab.com:group/subgroup/repo.git",
+			wantFork:     "git@gitlab.com:me/repo.git",
+		},
+		{
+			name:         "fork template takes precedence over an explicit URL",
+			repo:         "ssh://git@bitbucket.company.com:7999/scm/proj/repo.git",
+			wantUpstream: "ssh://git@bitbucket.company.com:7999/scm/proj/repo.git",
+			wantFork:     "ssh://git@bitbucket.company.com:7999/scm/~me/repo.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := ParseRepoString(tt.repo, "main")
+			require.NoError(t, err)
+			upstream, err := rc.UpstreamURL(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUpstream, upstream)
+			fork, err := rc.ForkURL(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFork, fork)
+		})
+	}
+}
+
+func TestRepoConfig_Port(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SetAccount("git.company.com", "me")
+	cfg.SetCloneMethod("git.company.com", config.CloneMethodSSH)
+	host := cfg.Hosts["git.company.com"]
+	host.URLs = map[config.CloneMethod]config.URLTemplates{
+		config.CloneMethodSSH: {Fork: "ssh://git@{{.Host}}:{{.Port}}/~{{.Account}}/{{.Repo}}.git"},
+	}
+	cfg.Hosts["git.company.com"] = host
+
+	t.Run("port of an SSH URL is used for generated URLs", func(t *testing.T) {
+		rc, err := ParseRepoString("ssh://git@git.company.com:2222/owner/repo.git", "main")
+		require.NoError(t, err)
+		fork, err := rc.ForkURL(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "ssh://git@git.company.com:2222/~me/repo.git", fork)
+	})
+
+	t.Run("port without a URL", func(t *testing.T) {
+		rc, err := ParseRepoString("git.company.com/owner/repo", "main")
+		require.NoError(t, err)
+		rc.Port = "2222"
+		upstream, err := rc.UpstreamURL(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "ssh://git@git.company.com:2222/owner/repo.git", upstream)
+		assert.Empty(t, cfg.GetHostConfig("git.company.com").Port, "the config is left alone")
+	})
+
+	t.Run("HTTPS ports are not SSH ports", func(t *testing.T) {
+		rc, err := ParseRepoString("https://git.company.com:8443/owner/repo.git", "main")
+		require.NoError(t, err)
+		assert.Empty(t, rc.Port)
+	})
+}
+
 func TestRepoConfig_IsGitHubEnterprise(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -115,3 +337,452 @@ func TestRepoConfig_IsGitHubEnterprise(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRepoString_Local(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mirror.git")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	t.Run("absolute path", func(t *testing.T) {
+		got, err := ParseRepoString(dir, "main")
+		require.NoError(t, err)
+		assert.Equal(t, &RepoConfig{RepoName: "mirror", Branch: "main", URL: dir, Local: true}, got)
+	})
+
+	t.Run("relative path is made absolute", func(t *testing.T) {
+		chdir(t, filepath.Dir(dir))
+		got, err := ParseRepoString("./mirror.git", "main")
+		require.NoError(t, err)
+		resolved, err := filepath.EvalSymlinks(got.URL)
+		require.NoError(t, err)
+		expected, err := filepath.EvalSymlinks(dir)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resolved)
+	})
+
+	t.Run("file URL is kept", func(t *testing.T) {
+		url := "file://" + filepath.ToSlash(dir)
+		got, err := ParseRepoString(url, "main")
+		require.NoError(t, err)
+		assert.Equal(t, &RepoConfig{RepoName: "mirror", Branch: "main", URL: url, Local: true}, got)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		_, err := ParseRepoString(filepath.Join(dir, "missing"), "main")
+		assert.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestSetupRepository_Local(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+
+	work := t.TempDir()
+	chdir(t, work)
+
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+	root, err := SetupRepository(rc, filepath.Join(t.TempDir(), "settings.yaml"), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(work, filepath.Base(src)), root)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, work, cwd, "setup must not change the working directory")
+	assert.DirExists(t, filepath.Join(root, ".bare"))
+	assert.FileExists(t, filepath.Join(root, ".git"))
+	assert.DirExists(t, filepath.Join(root, "main"))
+	assert.DirExists(t, filepath.Join(root, "review"))
+
+	output, err := exec.Command("git", "-C", filepath.Join(root, "main"), "remote", "get-url", "origin").Output()
+	require.NoError(t, err)
+	assert.Equal(t, rc.URL, strings.TrimSpace(string(output)))
+}
+
+func TestSetupRepository_Resume(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+
+	t.Run("completes missing steps", func(t *testing.T) {
+		work := t.TempDir()
+		_, err := SetupRepository(rc, settings, Options{Dir: work})
+		require.NoError(t, err)
+
+		root := filepath.Join(work, filepath.Base(src))
+		require.NoError(t, os.RemoveAll(filepath.Join(root, "review")))
+		require.NoError(t, os.Remove(filepath.Join(root, ".git")))
+		gittest.Run(t, root, "--git-dir=.bare", "remote", "set-url", "origin", "https://example.com/wrong.git")
+
+		_, err = SetupRepository(rc, settings, Options{Dir: work})
+		require.NoError(t, err)
+		assert.DirExists(t, filepath.Join(root, "review"))
+		assert.FileExists(t, filepath.Join(root, ".git"))
+		assert.Equal(t, rc.URL, gittest.Run(t, filepath.Join(root, "main"), "remote", "get-url", "origin"))
+	})
+
+	t.Run("replaces an incomplete clone", func(t *testing.T) {
+		work := t.TempDir()
+		root := filepath.Join(work, filepath.Base(src))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, ".bare"), 0755))
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work})
+		require.NoError(t, err)
+		assert.DirExists(t, filepath.Join(root, "main"))
+		assert.DirExists(t, filepath.Join(root, "review"))
+	})
+
+	t.Run("refuses to overwrite an unrelated directory", func(t *testing.T) {
+		work := t.TempDir()
+		root := filepath.Join(work, filepath.Base(src))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "review"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "review", "notes.txt"), []byte("keep"), 0644))
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work})
+		assert.ErrorContains(t, err, "was not created by setup")
+		assert.FileExists(t, filepath.Join(root, "review", "notes.txt"))
+	})
+
+	t.Run("refuses to set up inside an existing clone", func(t *testing.T) {
+		work := t.TempDir()
+		root := filepath.Join(work, filepath.Base(src))
+		require.NoError(t, os.Mkdir(root, 0755))
+		gittest.Run(t, root, "init")
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work})
+		assert.ErrorContains(t, err, "use 'wt adopt'")
+		assert.NoDirExists(t, filepath.Join(root, ".bare"))
+	})
+
+	t.Run("refuses to replace a non-empty worktree directory", func(t *testing.T) {
+		work := t.TempDir()
+		root := filepath.Join(work, filepath.Base(src))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, ".bare"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "review"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "review", "notes.txt"), []byte("keep"), 0644))
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work})
+		assert.ErrorContains(t, err, "is not a worktree")
+		assert.FileExists(t, filepath.Join(root, "review", "notes.txt"))
+	})
+}
+
+func TestSetupRepository_Name(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+
+	work := t.TempDir()
+	for _, name := range []string{"alice-fork", "bob-fork"} {
+		root, err := SetupRepository(rc, settings, Options{Dir: work, Name: name})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(work, name), root)
+		assert.DirExists(t, filepath.Join(root, "main"))
+		assert.Equal(t, "gitdir: "+filepath.Join(root, ".bare"), gitFile(t, root))
+	}
+
+	for _, name := range []string{"..", "nested/name"} {
+		_, err := SetupRepository(rc, settings, Options{Dir: work, Name: name})
+		assert.ErrorContains(t, err, "invalid repository folder name")
+	}
+}
+
+func TestSetupRepository_RemotesAndWorktrees(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+	gittest.Run(t, src, "branch", "existing")
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+
+	var out strings.Builder
+	opts := Options{
+		Dir:       t.TempDir(),
+		Remotes:   []Remote{{Name: "colleague", URL: "https://example.com/colleague/repo.git"}},
+		Worktrees: []Worktree{{Name: "existing"}, {Name: "feature/new"}, {Name: "main"}},
+		Out:       &out,
+	}
+	root, err := SetupRepository(rc, settings, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/colleague/repo.git", gittest.Run(t, root, "remote", "get-url", "colleague"))
+	assert.Equal(t, "existing", gittest.Run(t, filepath.Join(root, "existing"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "feature/new", gittest.Run(t, filepath.Join(root, "feature", "new"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Contains(t, out.String(), "Creating worktree for feature/new")
+
+	// Running again leaves everything in place
+	_, err = SetupRepository(rc, settings, opts)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Worktree feature/new already exists")
+}
+
+func TestSetupRepository_WorktreeLayout(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	cfg := config.DefaultConfig()
+	cfg.Worktrees.Layout = config.WorktreeLayoutSibling
+	require.NoError(t, cfg.SaveToPath(settings))
+
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+	work := t.TempDir()
+	root, err := SetupRepository(rc, settings, Options{Dir: work, Name: "repo", Worktrees: []Worktree{{Name: "feature/new"}}, Out: io.Discard})
+	require.NoError(t, err)
+
+	// The worktrees are placed where `wt add` would place them rather than in the root
+	worktrees := filepath.Join(work, "repo.worktrees")
+	assert.Equal(t, "main", gittest.Run(t, filepath.Join(worktrees, "main"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "review", gittest.Run(t, filepath.Join(worktrees, "review"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "feature/new", gittest.Run(t, filepath.Join(worktrees, "feature", "new"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.NoDirExists(t, filepath.Join(root, "main"))
+	assert.NoDirExists(t, filepath.Join(root, "review"))
+
+	// Running again finds them there
+	_, err = SetupRepository(rc, settings, Options{Dir: work, Name: "repo", Worktrees: []Worktree{{Name: "feature/new"}}, Out: io.Discard})
+	require.NoError(t, err)
+}
+
+func TestSetupRepository_ShallowClone(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "first")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "second")
+	gittest.Run(t, src, "branch", "other")
+
+	rc, err := ParseRepoString("file://"+filepath.ToSlash(src), "main")
+	require.NoError(t, err)
+	opts := Options{Dir: t.TempDir(), Clone: git.CloneOptions{Depth: 1, SingleBranch: true}, Out: io.Discard}
+	root, err := SetupRepository(rc, filepath.Join(t.TempDir(), "settings.yaml"), opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", gittest.Run(t, filepath.Join(root, "main"), "rev-list", "--count", "HEAD"))
+	assert.Equal(t, git.CloneOptions{Depth: 1, SingleBranch: true}, git.LoadCloneOptions(filepath.Join(root, ".bare")))
+}
+
+func TestSetupRepository_Cache(t *testing.T) {
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+
+	objects := cache.New(t.TempDir())
+	rc := &RepoConfig{Domain: "example.com", Org: "org", RepoName: "repo", Branch: "main", URL: "file://" + filepath.ToSlash(src)}
+	opts := Options{Dir: t.TempDir(), Cache: objects, Out: io.Discard}
+	root, err := SetupRepository(rc, filepath.Join(t.TempDir(), "settings.yaml"), opts)
+	require.NoError(t, err)
+
+	path := objects.Path("example.com", "org", "repo")
+	alternates, err := os.ReadFile(filepath.Join(root, ".bare", "objects", "info", "alternates"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(path, "objects"), strings.TrimSpace(string(alternates)))
+	assert.Equal(t, filepath.Join(root, ".bare"), gittest.Run(t, src, "--git-dir="+path, "config", "wt.dependent"))
+}
+
+func TestSetupRepository_HTTPSToken(t *testing.T) {
+	// Serve a repository over smart HTTP, requiring the token
+	projects := t.TempDir()
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+	gittest.Run(t, projects, "clone", "--bare", "--quiet", src, filepath.Join(projects, "org", "repo.git"))
+	backend := filepath.Join(gittest.Run(t, src, "--exec-path"), "git-http-backend")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != "token" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		(&cgi.Handler{Path: backend, Env: []string{"GIT_PROJECT_ROOT=" + projects, "GIT_HTTP_EXPORT_ALL=1"}}).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	t.Setenv("WT_TEST_TOKEN", "token")
+	t.Setenv("GIT_TERMINAL_PROMPT", "0")
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	cfg := config.DefaultConfig()
+	cfg.SetToken("127.0.0.1", "WT_TEST_TOKEN", "")
+	require.NoError(t, cfg.SaveToPath(settings))
+
+	rc, err := ParseRepoString(srv.URL+"/org/repo.git", "main")
+	require.NoError(t, err)
+	helper := `!f() { test "$1" = get && echo username=x && echo "password=$WT_TEST_TOKEN"; }; f`
+	root, err := SetupRepository(rc, settings, Options{Dir: t.TempDir(), CredentialHelper: helper, Out: io.Discard})
+	require.NoError(t, err)
+
+	// Later fetches authenticate through the helper configured in the clone
+	bare := filepath.Join(root, ".bare")
+	gittest.Run(t, root, "--git-dir="+bare, "fetch", "origin")
+	assert.Equal(t, helper, gittest.Run(t, root, "--git-dir="+bare, "config", "credential."+srv.URL+".helper"))
+}
+
+func TestSetupRepository_SSHIdentity(t *testing.T) {
+	// A stand-in for ssh records its arguments and serves repositories from a local directory
+	projects := t.TempDir()
+	src := t.TempDir()
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+	gittest.Run(t, projects, "clone", "--bare", "--quiet", src, filepath.Join(projects, "org", "repo.git"))
+	bin := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\nfor last; do :; done\ncd " + projects + " && eval \"$last\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	// GIT_SSH_COMMAND would take precedence over the recorded core.sshCommand
+	t.Setenv("GIT_SSH_COMMAND", "")
+	require.NoError(t, os.Unsetenv("GIT_SSH_COMMAND"))
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	cfg := config.DefaultConfig()
+	cfg.SetHostConfig("git.company.com", config.HostConfig{CloneMethod: config.CloneMethodSSH, IdentityFile: "/keys/id_work"})
+	require.NoError(t, cfg.SaveToPath(settings))
+
+	rc, err := ParseRepoString("git.company.com/org/repo", "main")
+	require.NoError(t, err)
+	// Partial clones are made with the git CLI, which runs ssh
+	root, err := SetupRepository(rc, settings, Options{Dir: t.TempDir(), Clone: git.CloneOptions{Filter: "blob:none"}, Out: io.Discard})
+	require.NoError(t, err)
+
+	// Later fetches use the identity too
+	bare := filepath.Join(root, ".bare")
+	gittest.Run(t, root, "--git-dir="+bare, "fetch", "origin")
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.GreaterOrEqual(t, len(lines), 2)
+	for _, line := range lines {
+		assert.Contains(t, line, "-i /keys/id_work -o IdentitiesOnly=yes")
+		assert.Contains(t, line, "git@git.company.com")
+	}
+}
+
+func TestSetupRepository_Profile(t *testing.T) {
+	// The profiles point the host at local repositories through URL templates
+	projects := t.TempDir()
+	for _, org := range []string{"acme-payments", "personal"} {
+		src := t.TempDir()
+		gittest.Run(t, src, "init", "--initial-branch=main")
+		gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+		gittest.Run(t, projects, "clone", "--bare", "--quiet", src, filepath.Join(projects, org, "repo.git"))
+	}
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	cfg := config.DefaultConfig()
+	cfg.SetHostConfig("git.company.com", config.HostConfig{UserName: "Jane Doe", UserEmail: "jane@example.com"})
+	cfg.SetProfile("git.company.com", config.Profile{Name: "work", Orgs: []string{"acme-*"}, HostConfig: config.HostConfig{
+		UserEmail: "jane@acme.com",
+		URLs:      map[config.CloneMethod]config.URLTemplates{config.CloneMethodHTTP: {Upstream: "file://" + filepath.ToSlash(projects) + "/{{.Org}}/{{.Repo}}.git"}},
+	}})
+	cfg.SetProfile("git.company.com", config.Profile{Name: "other", HostConfig: config.HostConfig{
+		URLs: map[config.CloneMethod]config.URLTemplates{config.CloneMethodHTTP: {Upstream: "file://" + filepath.ToSlash(projects) + "/personal/{{.Repo}}.git"}},
+	}})
+	require.NoError(t, cfg.SaveToPath(settings))
+
+	t.Run("chosen by org", func(t *testing.T) {
+		rc, err := ParseRepoString("git.company.com/acme-payments/repo", "main")
+		require.NoError(t, err)
+		var out strings.Builder
+		root, err := SetupRepository(rc, settings, Options{Dir: t.TempDir(), Out: &out})
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "Using the work profile for git.company.com")
+		bare := filepath.Join(root, ".bare")
+		assert.Equal(t, "work", gittest.Run(t, root, "--git-dir="+bare, "config", ProfileKey))
+		assert.Equal(t, "Jane Doe", gittest.Run(t, root, "--git-dir="+bare, "config", "--local", "user.name"))
+		assert.Equal(t, "jane@acme.com", gittest.Run(t, root, "--git-dir="+bare, "config", "--local", "user.email"))
+	})
+
+	t.Run("chosen by name", func(t *testing.T) {
+		rc, err := ParseRepoString("git.company.com/acme-payments/repo", "main")
+		require.NoError(t, err)
+		root, err := SetupRepository(rc, settings, Options{Dir: t.TempDir(), Profile: "other", Out: io.Discard})
+		require.NoError(t, err)
+		bare := filepath.Join(root, ".bare")
+		assert.Equal(t, "other", gittest.Run(t, root, "--git-dir="+bare, "config", ProfileKey))
+		assert.Equal(t, "jane@example.com", gittest.Run(t, root, "--git-dir="+bare, "config", "--local", "user.email"))
+		assert.Equal(t, "file://"+filepath.ToSlash(projects)+"/personal/repo.git", gittest.Run(t, root, "--git-dir="+bare, "remote", "get-url", "origin"))
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		rc, err := ParseRepoString("git.company.com/acme-payments/repo", "main")
+		require.NoError(t, err)
+		_, err = SetupRepository(rc, settings, Options{Dir: t.TempDir(), Profile: "missing", Out: io.Discard})
+		assert.ErrorContains(t, err, `no profile "missing"`)
+	})
+}
+
+func TestWorktree_YAML(t *testing.T) {
+	var worktrees []Worktree
+	require.NoError(t, yaml.Unmarshal([]byte("[feature, {name: wip, branch: pushed, upstream: origin/pushed}]"), &worktrees))
+	assert.Equal(t, []Worktree{{Name: "feature"}, {Name: "wip", Branch: "pushed", Upstream: "origin/pushed"}}, worktrees)
+
+	worktrees = append(worktrees, Worktree{Name: "same", Branch: "same"})
+	out, err := yaml.Marshal(worktrees)
+	require.NoError(t, err)
+	assert.Equal(t, "- feature\n- name: wip\n  branch: pushed\n  upstream: origin/pushed\n- same\n", string(out))
+}
+
+func TestSetupRepository_Cleanup(t *testing.T) {
+	// An existing directory that isn't a repository makes the clone fail
+	src := t.TempDir()
+	rc := &RepoConfig{RepoName: "broken", Branch: "main", URL: src, Local: true}
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+
+	t.Run("removes the directory when confirmed", func(t *testing.T) {
+		work := t.TempDir()
+
+		var asked string
+		_, err := SetupRepository(rc, settings, Options{Dir: work, ConfirmCleanup: func(dir string) bool {
+			asked = dir
+			return true
+		}})
+		require.Error(t, err)
+		assert.Equal(t, filepath.Join(work, "broken"), asked)
+		assert.NoDirExists(t, filepath.Join(work, "broken"))
+	})
+
+	t.Run("keeps the directory when declined", func(t *testing.T) {
+		work := t.TempDir()
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work, ConfirmCleanup: func(string) bool { return false }})
+		require.Error(t, err)
+		assert.DirExists(t, filepath.Join(work, "broken"))
+	})
+
+	t.Run("never removes a directory that already existed", func(t *testing.T) {
+		work := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(work, "broken"), 0755))
+
+		_, err := SetupRepository(rc, settings, Options{Dir: work, ConfirmCleanup: func(string) bool {
+			t.Fatal("cleanup should not be offered when resuming")
+			return true
+		}})
+		require.Error(t, err)
+		assert.DirExists(t, filepath.Join(work, "broken"))
+	})
+}
+
+func gitFile(t *testing.T, root string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(root, ".git"))
+	require.NoError(t, err)
+	return string(content)
+}
+
+// chdir changes the working directory for the duration of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	oldCwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(oldCwd) })
+}
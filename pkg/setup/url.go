@@ -0,0 +1,117 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package setup
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scpLikeURL matches scp-style addresses such as git@github.com:org/repo.git
+var scpLikeURL = regexp.MustCompile(`^(?:[^@/:]+@)?([^@/:]+):([^/].*)$`)
+
+// repoURL is a clone URL broken into the parts needed to configure a repository
+type repoURL struct {
+	Host string
+	// Port is only set for ssh:// URLs, as the port of other schemes isn't an SSH port
+	Port string
+	// Path is the repository path without leading/trailing slashes or the .git suffix
+	Path string
+}
+
+// parseRepoURL parses full clone URLs (https://, ssh://, git://) and scp-style addresses.
+// ok is false when raw is neither, i.e. it should be treated as [domain/]org/repo shorthand.
+func parseRepoURL(raw string) (u *repoURL, ok bool, err error) {
+	if strings.Contains(raw, "://") {
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return nil, true, fmt.Errorf("invalid repository URL %s: %w", raw, err)
+		}
+		switch parsed.Scheme {
+		case "https", "http", "ssh", "git", "git+ssh", "ssh+git":
+		default:
+			return nil, true, fmt.Errorf("unsupported repository URL scheme: %s", parsed.Scheme)
+		}
+		if parsed.Hostname() == "" {
+			return nil, true, fmt.Errorf("invalid repository URL %s: missing host", raw)
+		}
+		u := &repoURL{Host: parsed.Hostname(), Path: trimRepoPath(parsed.Path)}
+		if strings.Contains(parsed.Scheme, "ssh") {
+			u.Port = parsed.Port()
+		}
+		return u, true, nil
+	}
+
+	if m := scpLikeURL.FindStringSubmatch(raw); m != nil {
+		return &repoURL{Host: m[1], Path: trimRepoPath(m[2])}, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func trimRepoPath(path string) string {
+	path = strings.Trim(path, "/")
+	return strings.TrimSuffix(path, ".git")
+}
+
+// parseLocalSource recognises file:// URLs and filesystem paths. Paths must be absolute or start
+// with ./, ../ or ~/ so that they cannot be mistaken for [domain/]org/repo shorthand.
+// The returned source is absolute because git uses it from another working directory, the new repository root.
+func parseLocalSource(raw string) (source, name string, ok bool, err error) {
+	path := raw
+	switch {
+	case strings.HasPrefix(raw, "file://"):
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return "", "", true, fmt.Errorf("invalid repository URL %s: %w", raw, err)
+		}
+		if parsed.Path == "" {
+			return "", "", true, fmt.Errorf("invalid repository URL %s: missing path", raw)
+		}
+		path = parsed.Path
+		source = raw
+	case strings.HasPrefix(raw, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", true, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(home, raw[2:])
+	case filepath.IsAbs(raw), raw == ".", raw == "..",
+		strings.HasPrefix(raw, "./"), strings.HasPrefix(raw, "../"):
+	default:
+		return "", "", false, nil
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", "", true, fmt.Errorf("failed to resolve %s: %w", raw, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", "", true, fmt.Errorf("repository %s does not exist or is not a directory", raw)
+	}
+	if source == "" {
+		source = path
+	}
+
+	name = strings.TrimSuffix(filepath.Base(path), ".git")
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return "", "", true, fmt.Errorf("cannot derive a repository name from %s", raw)
+	}
+	return source, name, true, nil
+}
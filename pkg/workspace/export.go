@@ -0,0 +1,188 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"gopkg.in/yaml.v3"
+)
+
+// Export returns a manifest describing every repository under root, in the .bare layout or a standard
+// clone: its remotes, base branch, profile and worktrees with their branches and upstreams. Repository
+// directories are recorded relative to root. Worktrees are recorded by branch, so import places them
+// with the .bare layout wherever they were; only worktrees inside a .bare layout keep their name.
+// Worktrees with a detached HEAD are left out.
+func Export(root string) (*Manifest, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repoRoots, err := setup.FindClones(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(repoRoots) == 0 {
+		return nil, fmt.Errorf("no repositories found under %s", root)
+	}
+
+	m := &Manifest{}
+	for _, repoRoot := range repoRoots {
+		repo, err := exportRepo(root, repoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", repoRoot, err)
+		}
+		m.Repos = append(m.Repos, repo)
+	}
+	return m, nil
+}
+
+// Save writes the manifest to path as YAML
+func (m *Manifest) Save(path string) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workspace manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write workspace manifest: %w", err)
+	}
+	return nil
+}
+
+func exportRepo(root, repoRoot string) (Repo, error) {
+	// bare is the git directory, .git in a standard clone
+	bare := filepath.Join(repoRoot, ".bare")
+	standard := false
+	if _, err := os.Stat(bare); err != nil {
+		bare = filepath.Join(repoRoot, ".git")
+		standard = true
+	}
+
+	remotes, err := exportRemotes(bare)
+	if err != nil {
+		return Repo{}, err
+	}
+	source, err := setup.SourceURL(bare)
+	if err != nil {
+		return Repo{}, err
+	}
+
+	repo := Repo{Repo: source, Remotes: remotes}
+
+	if rel, err := filepath.Rel(root, filepath.Dir(repoRoot)); err == nil && repoRoot != root && rel != "." {
+		repo.Dir = filepath.ToSlash(rel)
+	}
+	if rc, err := setup.ParseRepoString(source, "main"); err != nil || rc.RepoName != filepath.Base(repoRoot) {
+		repo.Name = filepath.Base(repoRoot)
+	}
+
+	repo.Branch = baseBranch(bare, standard)
+
+	clone := git.LoadCloneOptions(bare)
+	repo.Filter, repo.Depth, repo.SingleBranch = clone.Filter, clone.Depth, clone.SingleBranch
+	repo.Profile, _ = git.RunGitCommandOutput("--git-dir="+bare, "config", setup.ProfileKey)
+
+	worktrees, err := git.ListWorktrees(repoRoot)
+	if err != nil {
+		return Repo{}, err
+	}
+	for _, wt := range worktrees {
+		if wt.Bare || wt.Branch == "" {
+			continue
+		}
+		exported := setup.Worktree{Branch: wt.Branch, Upstream: upstream(bare, wt.Branch)}
+		// Names only carry over within the .bare layout; elsewhere the worktree is placed by its branch
+		if name, err := filepath.Rel(repoRoot, wt.Path); err == nil && !standard && filepath.IsLocal(name) {
+			if name = filepath.ToSlash(name); name != wt.Branch {
+				exported.Name = name
+			}
+		}
+		if name := worktreeName(exported); name == repo.Branch || name == "review" {
+			// Setup always creates these
+			continue
+		}
+		repo.Worktrees = append(repo.Worktrees, exported)
+	}
+	sort.Slice(repo.Worktrees, func(i, j int) bool {
+		return worktreeName(repo.Worktrees[i]) < worktreeName(repo.Worktrees[j])
+	})
+
+	return repo, nil
+}
+
+// exportRemotes returns the remotes of the bare repository ordered by name
+func exportRemotes(bare string) ([]setup.Remote, error) {
+	output, err := git.RunGitCommandOutput("--git-dir="+bare, "remote")
+	if err != nil {
+		return nil, err
+	}
+	var remotes []setup.Remote
+	for _, name := range strings.Fields(output) {
+		url, err := git.RunGitCommandOutput("--git-dir="+bare, "remote", "get-url", name)
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, setup.Remote{Name: name, URL: url})
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Name < remotes[j].Name })
+	return remotes, nil
+}
+
+// worktreeName returns the directory setup creates the worktree in
+func worktreeName(wt setup.Worktree) string {
+	if wt.Name == "" {
+		return wt.Branch
+	}
+	return wt.Name
+}
+
+// baseBranch returns the base branch recorded by setup, falling back to the branch HEAD points to.
+// HEAD of a standard clone is whatever is checked out, so its remote's default branch comes first.
+func baseBranch(bare string, standard bool) string {
+	if branch, err := git.RunGitCommandOutput("--git-dir="+bare, "config", setup.BaseBranchKey); err == nil && branch != "" {
+		return branch
+	}
+	if standard {
+		for _, remote := range []string{"upstream", "origin"} {
+			if ref, err := git.RunGitCommandOutput("--git-dir="+bare, "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD"); err == nil && ref != "" {
+				return strings.TrimPrefix(ref, remote+"/")
+			}
+		}
+	}
+	if branch, err := git.RunGitCommandOutput("--git-dir="+bare, "symbolic-ref", "--short", "HEAD"); err == nil {
+		return branch
+	}
+	return ""
+}
+
+// upstream returns the upstream of branch as <remote>/<branch>, or "" when it has none
+func upstream(bare, branch string) string {
+	remote, err := git.RunGitCommandOutput("--git-dir="+bare, "config", "branch."+branch+".remote")
+	if err != nil || remote == "" || remote == "." {
+		return ""
+	}
+	merge, err := git.RunGitCommandOutput("--git-dir="+bare, "config", "branch."+branch+".merge")
+	if err != nil || merge == "" {
+		return ""
+	}
+	return remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+}
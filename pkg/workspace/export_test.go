@@ -0,0 +1,136 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	a := newSource(t, "service-a")
+	b := newSource(t, "service-b")
+	gittest.Run(t, b, "branch", "develop")
+	gittest.Run(t, b, "branch", "pushed")
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+
+	// Build a workspace to export
+	src := t.TempDir()
+	m := &Manifest{
+		Dir: src,
+		Repos: []Repo{
+			{Repo: a, Dir: "team", Worktrees: []setup.Worktree{{Name: "feature/x"}}},
+			{Repo: b, Branch: "develop", Name: "b-fork", Remotes: []setup.Remote{{Name: "colleague", URL: a}}},
+		},
+	}
+	results, err := Setup(m, settings, Options{Out: io.Discard})
+	require.NoError(t, err)
+	require.Zero(t, Failed(results))
+	bRoot := filepath.Join(src, "b-fork")
+	gittest.Run(t, bRoot, "worktree", "add", "wip", "pushed")
+	gittest.Run(t, bRoot, "config", "branch.pushed.remote", "origin")
+	gittest.Run(t, bRoot, "config", "branch.pushed.merge", "refs/heads/pushed")
+
+	exported, err := Export(src)
+	require.NoError(t, err)
+	require.Len(t, exported.Repos, 2)
+
+	assert.Equal(t, Repo{
+		Repo:    b,
+		Branch:  "develop",
+		Name:    "b-fork",
+		Remotes: []setup.Remote{{Name: "colleague", URL: a}, {Name: "origin", URL: b}},
+		Worktrees: []setup.Worktree{
+			{Name: "wip", Branch: "pushed", Upstream: "origin/pushed"},
+		},
+	}, exported.Repos[0])
+	assert.Equal(t, Repo{
+		Repo:      a,
+		Branch:    "main",
+		Dir:       "team",
+		Remotes:   []setup.Remote{{Name: "origin", URL: a}},
+		Worktrees: []setup.Worktree{{Branch: "feature/x"}},
+	}, exported.Repos[1])
+
+	// Round trip through YAML and recreate the workspace elsewhere
+	path := filepath.Join(t.TempDir(), "workspace.yaml")
+	require.NoError(t, exported.Save(path))
+	imported, err := Load(path)
+	require.NoError(t, err)
+
+	dst := t.TempDir()
+	imported.Dir = dst
+	results, err = Setup(imported, settings, Options{Out: io.Discard})
+	require.NoError(t, err)
+	require.Zero(t, Failed(results))
+
+	assert.DirExists(t, filepath.Join(dst, "team", "service-a", "feature", "x"))
+	wip := filepath.Join(dst, "b-fork", "wip")
+	assert.Equal(t, "pushed", gittest.Run(t, wip, "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "origin", gittest.Run(t, wip, "config", "branch.pushed.remote"))
+	assert.Equal(t, a, gittest.Run(t, wip, "remote", "get-url", "colleague"))
+	assert.Equal(t, "develop", gittest.Run(t, wip, "config", setup.BaseBranchKey))
+}
+
+func TestExportImport_StandardClone(t *testing.T) {
+	a := newSource(t, "service-a")
+	gittest.Run(t, a, "branch", "topic")
+	gittest.Run(t, a, "branch", "feature")
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+
+	// A standard clone with its checkout on a topic branch and a worktree next to it
+	src := t.TempDir()
+	clone := filepath.Join(src, "apps", "service-a")
+	require.NoError(t, os.MkdirAll(filepath.Dir(clone), 0755))
+	gittest.Run(t, filepath.Dir(clone), "clone", a, clone)
+	gittest.Run(t, clone, "checkout", "topic")
+	gittest.Run(t, clone, "worktree", "add", filepath.Join(src, "apps", "service-a.worktrees", "feature"), "feature")
+
+	exported, err := Export(src)
+	require.NoError(t, err)
+	require.Len(t, exported.Repos, 1)
+	assert.Equal(t, Repo{
+		Repo:    a,
+		Branch:  "main",
+		Dir:     "apps",
+		Remotes: []setup.Remote{{Name: "origin", URL: a}},
+		Worktrees: []setup.Worktree{
+			{Branch: "feature", Upstream: "origin/feature"},
+			{Branch: "topic", Upstream: "origin/topic"},
+		},
+	}, exported.Repos[0])
+
+	// Import recreates it in the .bare layout with a worktree per branch
+	dst := t.TempDir()
+	exported.Dir = dst
+	results, err := Setup(exported, settings, Options{Out: io.Discard})
+	require.NoError(t, err)
+	require.Zero(t, Failed(results))
+	assert.DirExists(t, filepath.Join(dst, "apps", "service-a", ".bare"))
+	assert.Equal(t, "feature", gittest.Run(t, filepath.Join(dst, "apps", "service-a", "feature"), "rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal(t, "topic", gittest.Run(t, filepath.Join(dst, "apps", "service-a", "topic"), "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestExport_NoRepositories(t *testing.T) {
+	_, err := Export(t.TempDir())
+	assert.ErrorContains(t, err, "no repositories found")
+}
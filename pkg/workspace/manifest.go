@@ -0,0 +1,185 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamawhite/worktree/pkg/git"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJobs is the number of repositories set up at the same time when the manifest doesn't say
+const DefaultJobs = 4
+
+// Manifest lists the repositories of a workspace
+type Manifest struct {
+	// Dir is the directory repositories are created in unless they set their own. Defaults to the current directory.
+	Dir string `yaml:"dir,omitempty"`
+	// Jobs limits how many repositories are set up at the same time
+	Jobs  int    `yaml:"jobs,omitempty"`
+	Repos []Repo `yaml:"repos"`
+}
+
+// Repo is a repository in the manifest. It is identified either by Repo, which accepts anything
+// `wt setup` does, or by Domain, Org and RepoName.
+type Repo struct {
+	Repo     string `yaml:"repo,omitempty"`
+	Domain   string `yaml:"domain,omitempty"`
+	Org      string `yaml:"org,omitempty"`
+	RepoName string `yaml:"repo_name,omitempty"`
+	// Port is the SSH port of the host, used in the clone URLs generated for the repository
+	Port string `yaml:"port,omitempty"`
+	// Branch is the base branch, defaulting to the branch passed to Setup
+	Branch string `yaml:"branch,omitempty"`
+	// Dir is the directory the repository is created in. Relative paths are resolved against the manifest's directory.
+	Dir string `yaml:"dir,omitempty"`
+	// Name is the local folder name, defaulting to the repository name
+	Name string `yaml:"name,omitempty"`
+	// Filter, Depth and SingleBranch make a partial or shallow clone, as the setup flags of the same names do
+	Filter       string `yaml:"filter,omitempty"`
+	Depth        int    `yaml:"depth,omitempty"`
+	SingleBranch bool   `yaml:"single_branch,omitempty"`
+	// Profile names the host profile to set the repository up with, as the setup flag --profile does
+	Profile   string           `yaml:"profile,omitempty"`
+	Remotes   []setup.Remote   `yaml:"remotes,omitempty"`
+	Worktrees []setup.Worktree `yaml:"worktrees,omitempty"`
+}
+
+// Load reads and validates the manifest at path
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workspace manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Validate checks that every repository is identified and that no two repositories share a folder
+func (m *Manifest) Validate() error {
+	if len(m.Repos) == 0 {
+		return fmt.Errorf("no repos listed")
+	}
+	if m.Jobs < 0 {
+		return fmt.Errorf("jobs must be positive")
+	}
+
+	roots := map[string]string{}
+	for i, repo := range m.Repos {
+		rc, err := repo.RepoConfig("main")
+		if err != nil {
+			return fmt.Errorf("repos[%d]: %w", i, err)
+		}
+		if repo.Depth < 0 {
+			return fmt.Errorf("repos[%d]: depth must be positive", i)
+		}
+		for _, remote := range repo.Remotes {
+			if remote.Name == "" || remote.URL == "" {
+				return fmt.Errorf("repos[%d]: remotes need a name and a url", i)
+			}
+		}
+
+		root, err := m.options(repo).Root(rc)
+		if err != nil {
+			return fmt.Errorf("repos[%d]: %w", i, err)
+		}
+		if other, ok := roots[root]; ok {
+			return fmt.Errorf("%s and %s would both be set up in %s, give one of them a different name or dir", other, repo, root)
+		}
+		roots[root] = repo.String()
+	}
+	return nil
+}
+
+// RepoConfig returns the setup configuration of the repository, using defaultBranch when it doesn't set one
+func (r Repo) RepoConfig(defaultBranch string) (*setup.RepoConfig, error) {
+	branch := r.Branch
+	if branch == "" {
+		branch = defaultBranch
+	}
+
+	if r.Repo != "" {
+		if r.Domain != "" || r.Org != "" || r.RepoName != "" || r.Port != "" {
+			return nil, fmt.Errorf("%s: use either repo or domain, org and repo_name", r.Repo)
+		}
+		return setup.ParseRepoString(r.Repo, branch)
+	}
+
+	if r.Org == "" || r.RepoName == "" {
+		return nil, fmt.Errorf("either repo or org and repo_name must be set")
+	}
+	domain := r.Domain
+	if domain == "" {
+		domain = "github.com"
+	}
+	rc, err := setup.ParseRepoString(strings.Join([]string{domain, r.Org, r.RepoName}, "/"), branch)
+	if err != nil {
+		return nil, err
+	}
+	rc.Port = r.Port
+	return rc, nil
+}
+
+// String identifies the repository in messages and the summary
+func (r Repo) String() string {
+	if r.Repo != "" {
+		return r.Repo
+	}
+	domain := r.Domain
+	if domain == "" {
+		domain = "github.com"
+	}
+	return strings.Join([]string{domain, r.Org, r.RepoName}, "/")
+}
+
+// options returns the setup options for a repository in the manifest
+func (m *Manifest) options(r Repo) setup.Options {
+	dir := expandHome(r.Dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(expandHome(m.Dir), dir)
+	}
+	return setup.Options{
+		Dir:       dir,
+		Name:      r.Name,
+		Remotes:   r.Remotes,
+		Worktrees: r.Worktrees,
+		Clone:     git.CloneOptions{Filter: r.Filter, Depth: r.Depth, SingleBranch: r.SingleBranch},
+		Profile:   r.Profile,
+	}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/liamawhite/worktree/pkg/cache"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/progress"
+	"github.com/liamawhite/worktree/pkg/setup"
+)
+
+// Options configures Setup
+type Options struct {
+	// Jobs overrides the manifest's concurrency when positive
+	Jobs int
+	// Branch is the base branch of repositories that don't set one
+	Branch string
+	// Cache is the shared object cache repositories borrow objects from, nil to clone without one
+	Cache *cache.Cache
+	// CredentialHelper is passed on to every repository's setup
+	CredentialHelper string
+	// Progress renders the progress of every repository. Defaults to plain lines on Out.
+	Progress progress.Reporter
+	// Out receives plain progress lines, each prefixed with the repository, when Progress is nil. Defaults to os.Stdout.
+	Out io.Writer
+}
+
+// Result is the outcome of setting up one repository
+type Result struct {
+	Repo     string
+	Root     string
+	Duration time.Duration
+	Err      error
+}
+
+// Setup sets up every repository in the manifest, running up to Jobs setups at the same time.
+// Results are returned in manifest order. Failed repositories are left in place so that running
+// Setup again resumes them.
+func Setup(m *Manifest, configPath string, opts Options) ([]Result, error) {
+	// Load the config once up front so concurrent setups don't race to create it
+	if _, err := config.LoadConfigFromPath(configPath); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = m.Jobs
+	}
+	if jobs <= 0 {
+		jobs = DefaultJobs
+	}
+	branch := opts.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	reporter := opts.Progress
+	if reporter == nil {
+		out := opts.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		reporter = progress.NewPlain(out)
+	}
+
+	results := make([]Result, len(m.Repos))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, repo := range m.Repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			w := progress.NewWriter(reporter, repo.String())
+			repoOpts := m.options(repo)
+			repoOpts.Cache = opts.Cache
+			repoOpts.CredentialHelper = opts.CredentialHelper
+			results[i] = setupRepo(repo, repoOpts, configPath, branch, w)
+			w.Flush()
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func setupRepo(repo Repo, opts setup.Options, configPath, branch string, out io.Writer) Result {
+	start := time.Now()
+	result := Result{Repo: repo.String()}
+
+	rc, err := repo.RepoConfig(branch)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	opts.Out = out
+	result.Root, result.Err = setup.SetupRepository(rc, configPath, opts)
+	result.Duration = time.Since(start)
+	return result
+}
+
+// Failed returns the number of results with an error
+func Failed(results []Result) int {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteSummary writes a table of the results to w
+func WriteSummary(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPO\tSTATUS\tTIME\tPATH")
+	for _, r := range results {
+		status, detail := "ok", r.Root
+		if r.Err != nil {
+			status, detail = "failed", r.Err.Error()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Repo, status, r.Duration.Round(time.Second), detail)
+	}
+	return tw.Flush()
+}
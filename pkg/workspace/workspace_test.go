@@ -0,0 +1,164 @@
+// Copyright 2025 Liam White
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/config"
+	"github.com/liamawhite/worktree/pkg/setup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSource creates a repository with a single commit on main
+func newSource(t *testing.T, name string) string {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.Mkdir(src, 0755))
+	gittest.Run(t, src, "init", "--initial-branch=main")
+	gittest.Run(t, src, "commit", "--allow-empty", "-m", "initial commit")
+	return src
+}
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workspace.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load(writeManifest(t, `
+dir: ~/src
+jobs: 2
+repos:
+  - repo: github.com/org/service-a
+    branch: develop
+  - domain: gitlab.company.com
+    org: platform/team
+    repo_name: service-b
+    name: service-b-fork
+    remotes:
+      - name: colleague
+        url: git@gitlab.company.com:colleague/service-b.git
+    worktrees: [feature/x]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Jobs)
+	require.Len(t, m.Repos, 2)
+
+	rc, err := m.Repos[0].RepoConfig("main")
+	require.NoError(t, err)
+	assert.Equal(t, "develop", rc.Branch)
+
+	rc, err = m.Repos[1].RepoConfig("main")
+	require.NoError(t, err)
+	assert.Equal(t, "gitlab.company.com", rc.Domain)
+	assert.Equal(t, "platform/team", rc.Org)
+	assert.Equal(t, "main", rc.Branch)
+	assert.Equal(t, "gitlab.company.com/platform/team/service-b", m.Repos[1].String())
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	opts := m.options(m.Repos[1])
+	assert.Equal(t, filepath.Join(home, "src"), opts.Dir)
+	assert.Equal(t, "service-b-fork", opts.Name)
+	assert.Equal(t, []setup.Worktree{{Name: "feature/x"}}, opts.Worktrees)
+}
+
+func TestLoad_Port(t *testing.T) {
+	m, err := Load(writeManifest(t, `
+repos:
+  - domain: git.company.com
+    org: platform
+    repo_name: service
+    port: "2222"
+`))
+	require.NoError(t, err)
+
+	cfg := config.DefaultConfig()
+	cfg.SetCloneMethod("git.company.com", config.CloneMethodSSH)
+	rc, err := m.Repos[0].RepoConfig("main")
+	require.NoError(t, err)
+	url, err := rc.UpstreamURL(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "ssh://git@git.company.com:2222/platform/service.git", url)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{name: "no repos", manifest: "dir: /tmp\n", err: "no repos listed"},
+		{name: "unidentified repo", manifest: "repos:\n  - branch: main\n", err: "either repo or org and repo_name must be set"},
+		{name: "repo and fields", manifest: "repos:\n  - repo: github.com/org/a\n    org: org\n", err: "use either repo or domain"},
+		{name: "repo and port", manifest: "repos:\n  - repo: github.com/org/a\n    port: \"2222\"\n", err: "use either repo or domain"},
+		{name: "remote without url", manifest: "repos:\n  - repo: github.com/org/a\n    remotes: [{name: fork}]\n", err: "remotes need a name and a url"},
+		{name: "same folder", manifest: "repos:\n  - repo: github.com/org/a\n  - repo: github.com/other/a\n", err: "would both be set up in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeManifest(t, tt.manifest))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	a := newSource(t, "service-a")
+	b := newSource(t, "service-b")
+	gittest.Run(t, b, "branch", "develop")
+	broken := t.TempDir()
+
+	dir := t.TempDir()
+	m := &Manifest{
+		Dir: dir,
+		Repos: []Repo{
+			{Repo: a, Worktrees: []setup.Worktree{{Name: "feature/x"}}},
+			{Repo: b, Branch: "develop", Name: "b"},
+			{Repo: broken, Name: "broken"},
+		},
+	}
+	require.NoError(t, m.Validate())
+
+	var out strings.Builder
+	results, err := Setup(m, filepath.Join(t.TempDir(), "settings.yaml"), Options{Jobs: 2, Out: &out})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, filepath.Join(dir, "service-a"), results[0].Root)
+	assert.DirExists(t, filepath.Join(dir, "service-a", "feature", "x"))
+	assert.NoError(t, results[1].Err)
+	assert.DirExists(t, filepath.Join(dir, "b", "develop"))
+	assert.Error(t, results[2].Err)
+	assert.Equal(t, 1, Failed(results))
+
+	assert.Contains(t, out.String(), "["+a+"] Creating worktree for feature/x")
+
+	var summary strings.Builder
+	require.NoError(t, WriteSummary(&summary, results))
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "ok")
+	assert.Contains(t, lines[3], "failed")
+}
@@ -17,42 +17,133 @@ package worktree
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/liamawhite/worktree/pkg/config"
 	"github.com/liamawhite/worktree/pkg/git"
 )
 
 //go:embed templates/post-add.sh
 var postAddHookTemplate string
 
+// Layout describes how a repository and its worktrees are arranged on disk
+type Layout string
+
+const (
+	// LayoutBare is the layout created by setup: a .bare repository with the worktrees next to it
+	LayoutBare Layout = "bare"
+	// LayoutStandard is an ordinary clone whose worktrees live in a separate directory
+	LayoutStandard Layout = "standard"
+)
+
 type WorktreeManager struct {
 	GitRoot string
+	// Layout defaults to LayoutBare when empty
+	Layout Layout
+	// PathTemplate places new worktrees (see config.RenderWorktreePath); they are created in GitRoot when empty
+	PathTemplate string
+	// GitDir is the git directory shared by all worktrees; standard clones keep their hooks there
+	GitDir string
+}
+
+// WorktreeInfo describes a worktree for display purposes
+type WorktreeInfo struct {
+	Path   string
+	Name   string
+	Branch string
+	Dirty  bool
 }
 
 func NewWorktreeManager() (*WorktreeManager, error) {
+	return NewWorktreeManagerWithConfig(config.DefaultConfig())
+}
+
+// NewWorktreeManagerWithConfig finds the repository containing the current directory, detects
+// whether it uses the bare layout or is a standard clone, and applies the configured worktree layout
+func NewWorktreeManagerWithConfig(cfg *config.Config) (*WorktreeManager, error) {
 	gitRoot, err := git.FindGitRoot()
 	if err != nil {
 		return nil, err
 	}
-	return &WorktreeManager{GitRoot: gitRoot}, nil
+
+	wm := &WorktreeManager{GitRoot: gitRoot, Layout: LayoutBare}
+	if _, err := os.Stat(filepath.Join(gitRoot, ".bare")); err != nil {
+		wm.Layout = LayoutStandard
+		if wm.GitDir, err = git.GitCommonDir(gitRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	if wm.PathTemplate, err = cfg.WorktreePathTemplate(wm.IsStandard()); err != nil {
+		return nil, err
+	}
+	// Render once so template errors are reported up front rather than when adding a worktree
+	if _, err := config.RenderWorktreePath(wm.PathTemplate, gitRoot, "branch"); err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// IsStandard reports whether the repository is a standard clone rather than the bare layout
+func (wm *WorktreeManager) IsStandard() bool {
+	return wm.Layout == LayoutStandard
+}
+
+// WorktreePath returns the path a worktree for the given branch is created at
+func (wm *WorktreeManager) WorktreePath(branch string) string {
+	if wm.PathTemplate != "" {
+		if path, err := config.RenderWorktreePath(wm.PathTemplate, wm.GitRoot, branch); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(wm.GitRoot, branch)
 }
 
+// GetWorktreeDirs returns the worktrees git has recorded for the repository, wherever they are located.
+// For standard clones the main checkout is listed first; the rest are ordered by name.
 func (wm *WorktreeManager) GetWorktreeDirs() ([]string, error) {
-	entries, err := os.ReadDir(wm.GitRoot)
+	worktrees, err := git.ListWorktrees(wm.GitRoot)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
 
-	var dirs []string
-	for _, entry := range entries {
-		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
-			dirs = append(dirs, filepath.Join(wm.GitRoot, entry.Name()))
+	var main, dirs []string
+	for _, wt := range worktrees {
+		if wt.Bare {
+			continue
+		}
+		// Worktrees whose directory was deleted without git knowing are skipped until pruned
+		if _, err := os.Stat(wt.Path); err != nil {
+			continue
+		}
+		if wm.IsStandard() && wm.isMainCheckout(wt.Path) {
+			main = append(main, wt.Path)
+			continue
 		}
+		dirs = append(dirs, wt.Path)
 	}
-	return dirs, nil
+
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return filepath.Base(dirs[i]) < filepath.Base(dirs[j])
+	})
+	return append(main, dirs...), nil
+}
+
+// isMainCheckout reports whether path is the repository root, comparing resolved paths
+func (wm *WorktreeManager) isMainCheckout(path string) bool {
+	root, err := filepath.EvalSymlinks(wm.GitRoot)
+	if err != nil {
+		root = wm.GitRoot
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path == root
 }
 
 func (wm *WorktreeManager) GetFilteredWorktrees() ([]string, error) {
@@ -63,15 +154,130 @@ func (wm *WorktreeManager) GetFilteredWorktrees() ([]string, error) {
 
 	var filtered []string
 	for _, dir := range dirs {
-		name := filepath.Base(dir)
-		if name != "main" && name != "master" && name != "review" {
+		if !wm.isProtectedPath(dir) {
 			filtered = append(filtered, dir)
 		}
 	}
 	return filtered, nil
 }
 
+// isProtected reports whether a worktree is one of the long-lived worktrees that are never removed
+func isProtected(name string) bool {
+	return name == "main" || name == "master" || name == "review"
+}
+
+// isProtectedPath also protects the main checkout of a standard clone, which cannot be removed as a worktree
+func (wm *WorktreeManager) isProtectedPath(path string) bool {
+	return isProtected(filepath.Base(path)) || (wm.IsStandard() && wm.isMainCheckout(path))
+}
+
+// CheckRemovable verifies that a worktree can be safely removed
+func (wm *WorktreeManager) CheckRemovable(worktreePath string) error {
+	name := filepath.Base(worktreePath)
+	if wm.isProtectedPath(worktreePath) {
+		return fmt.Errorf("worktree '%s' is protected and cannot be removed", name)
+	}
+
+	dirs, err := wm.GetWorktreeDirs()
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if dir == worktreePath {
+			return nil
+		}
+	}
+	return fmt.Errorf("worktree '%s' not found", name)
+}
+
+// GetWorktreeInfo resolves the branch and working tree status of each worktree path
+func (wm *WorktreeManager) GetWorktreeInfo(paths []string) []WorktreeInfo {
+	infos := wm.GetWorktreeBranches(paths)
+	for i := range infos {
+		infos[i].Dirty = wm.IsDirty(infos[i].Path)
+	}
+	return infos
+}
+
+// GetWorktreeBranches resolves the branch of each worktree path without the working tree status,
+// which is slow in large repositories and can be loaded separately with IsDirty
+func (wm *WorktreeManager) GetWorktreeBranches(paths []string) []WorktreeInfo {
+	infos := make([]WorktreeInfo, 0, len(paths))
+	for _, path := range paths {
+		info := WorktreeInfo{
+			Path: path,
+			Name: filepath.Base(path),
+		}
+		if branch, err := git.CurrentBranch(path); err == nil {
+			info.Branch = branch
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// IsDirty reports whether a worktree has uncommitted changes
+func (wm *WorktreeManager) IsDirty(worktreePath string) bool {
+	status, err := git.StatusShort(worktreePath)
+	return err == nil && status != ""
+}
+
+// BaseBranchKey is the git config key holding the branch worktrees are based on, recorded by setup and adopt
+const BaseBranchKey = "wt.base"
+
+// BaseBranch returns the branch that worktrees are based on and compared against: the recorded
+// base branch, otherwise main or master
+func (wm *WorktreeManager) BaseBranch() string {
+	if base, err := git.RunGitCommandOutput("--git-dir="+wm.commonDir(), "config", BaseBranchKey); err == nil && base != "" {
+		return base
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := git.RunGitCommandOutputInDir(wm.GitRoot, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch
+		}
+	}
+	return "HEAD"
+}
+
+// Preview summarises a worktree's status, recent commits and diffstat against the base branch
+func (wm *WorktreeManager) Preview(worktreePath string) string {
+	var b strings.Builder
+
+	status, err := git.StatusShort(worktreePath)
+	b.WriteString("Status\n")
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "  unavailable: %v\n", err)
+	case status == "":
+		b.WriteString("  clean\n")
+	default:
+		b.WriteString(status + "\n")
+	}
+
+	b.WriteString("\nRecent commits\n")
+	if commits, err := git.RecentCommits(worktreePath, 5); err == nil && commits != "" {
+		b.WriteString(commits + "\n")
+	} else {
+		b.WriteString("  none\n")
+	}
+
+	base := wm.BaseBranch()
+	fmt.Fprintf(&b, "\nChanges against %s\n", base)
+	if diffStat, err := git.DiffStat(worktreePath, base); err == nil && diffStat != "" {
+		b.WriteString(diffStat + "\n")
+	} else {
+		b.WriteString("  none\n")
+	}
+
+	return b.String()
+}
+
+// GetHooksDir returns the directory holding the worktree hooks. Standard clones keep them in the
+// git directory so they don't show up in the checkout.
 func (wm *WorktreeManager) GetHooksDir() string {
+	if wm.IsStandard() && wm.GitDir != "" {
+		return filepath.Join(wm.GitDir, "worktree-hooks")
+	}
 	return filepath.Join(wm.GitRoot, ".hooks")
 }
 
@@ -110,39 +316,99 @@ func (wm *WorktreeManager) CreateHooks(base, branch string) error {
 	return tmpl.Execute(file, data)
 }
 
-func (wm *WorktreeManager) RunPostAddHook(worktreePath string) error {
+func (wm *WorktreeManager) RunPostAddHook(out io.Writer, worktreePath string) error {
 	hookPath := wm.GetPostAddHook()
 	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
 		return nil
 	}
 
-	return git.RunCommandInDir(worktreePath, "sh", hookPath)
+	return runTo(out, worktreePath, "sh", hookPath)
+}
+
+// CommandWriter is an output writer that is also told which commands produce the output, such as
+// the log panel of the dashboard
+type CommandWriter interface {
+	io.Writer
+	Command(name string, args ...string)
+}
+
+// runTo runs a command in dir writing its output to out, announcing it first when out is a CommandWriter
+func runTo(out io.Writer, dir, name string, args ...string) error {
+	if w, ok := out.(CommandWriter); ok {
+		w.Command(name, args...)
+	}
+	return git.RunCommandInDirTo(out, dir, name, args...)
+}
+
+// commonDir returns the git directory shared by all worktrees
+func (wm *WorktreeManager) commonDir() string {
+	if wm.GitDir != "" {
+		return wm.GitDir
+	}
+	return filepath.Join(wm.GitRoot, ".bare")
 }
 
-func (wm *WorktreeManager) AddWorktree(branch, base string) error {
+// CloneOptions returns how the repository was cloned, so fetches can keep a shallow clone shallow
+func (wm *WorktreeManager) CloneOptions() git.CloneOptions {
+	return git.LoadCloneOptions(wm.commonDir())
+}
+
+// EnsureBase fetches the base branch from origin when a shallow or single-branch clone doesn't have it
+// yet, writing git's output to out
+func (wm *WorktreeManager) EnsureBase(out io.Writer, base string) error {
+	if base == "" {
+		return nil
+	}
+	if _, err := git.RunGitCommandOutputInDir(wm.GitRoot, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err == nil {
+		return nil
+	}
+	opts := wm.CloneOptions()
+	if opts.IsZero() {
+		// A full clone that doesn't have the base can't get it this way; let git report the error
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Fetching %s from origin\n", base)
+	if err := git.FetchBranch(out, wm.commonDir(), "origin", base, opts); err != nil {
+		return fmt.Errorf("failed to fetch base branch %s: %w", base, err)
+	}
+	return nil
+}
+
+// AddWorktree creates a worktree with a new branch from base, defaulting to the base branch, and runs
+// the post-add hook in it. Output of git and the hook is written to out.
+func (wm *WorktreeManager) AddWorktree(out io.Writer, branch, base string) error {
 	if base == "" {
-		base = "main"
+		base = wm.BaseBranch()
 	}
 
-	if err := os.Chdir(wm.GitRoot); err != nil {
+	if err := wm.EnsureBase(out, base); err != nil {
 		return err
 	}
 
-	if err := git.RunGitCommand("worktree", "add", "-b", branch, branch, base); err != nil {
+	worktreePath := wm.WorktreePath(branch)
+	if err := runTo(out, wm.GitRoot, "git", "worktree", "add", "-b", branch, worktreePath, base); err != nil {
 		return err
 	}
 
-	worktreePath := filepath.Join(wm.GitRoot, branch)
-	if err := wm.RunPostAddHook(worktreePath); err != nil {
+	if err := wm.RunPostAddHook(out, worktreePath); err != nil {
 		return fmt.Errorf("failed to run post-add hook: %w", err)
 	}
 
 	return nil
 }
 
-func (wm *WorktreeManager) RemoveWorktree(worktreePath string) (bool, error) {
-	worktreeName := filepath.Base(worktreePath)
+// worktreeBranch returns the branch checked out in a worktree. Worktrees are named after their
+// branch by default, so the directory name is used when the branch can't be determined.
+func worktreeBranch(worktreePath string) string {
+	if branch, err := git.CurrentBranch(worktreePath); err == nil && branch != "HEAD" {
+		return branch
+	}
+	return filepath.Base(worktreePath)
+}
 
+// RemoveWorktree removes the worktree and deletes its branch, writing git's output to out. Reports
+// whether the current directory was inside the worktree.
+func (wm *WorktreeManager) RemoveWorktree(out io.Writer, worktreePath string) (bool, error) {
 	// Check if we're currently in the worktree we're about to remove
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -150,16 +416,21 @@ func (wm *WorktreeManager) RemoveWorktree(worktreePath string) (bool, error) {
 	}
 
 	needsChdir := strings.HasPrefix(currentDir, worktreePath)
+	return needsChdir, wm.removeWorktree(out, worktreePath)
+}
 
-	if err := git.RunGitCommand("worktree", "remove", worktreeName, "--force"); err != nil {
-		return needsChdir, err
-	}
+func (wm *WorktreeManager) removeWorktree(out io.Writer, worktreePath string) error {
+	branch := worktreeBranch(worktreePath)
 
-	if err := git.DeleteBranch(wm.GitRoot, worktreeName); err != nil {
-		return needsChdir, err
+	if err := runTo(out, wm.GitRoot, "git", "worktree", "remove", worktreePath, "--force"); err != nil {
+		return err
 	}
 
-	return needsChdir, nil
+	if err := git.DeleteBranch(wm.GitRoot, branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", branch, err)
+	}
+	_, _ = fmt.Fprintf(out, "Deleted branch %s\n", branch)
+	return nil
 }
 
 func (wm *WorktreeManager) ClearWorktrees() (bool, error) {
@@ -186,21 +457,12 @@ func (wm *WorktreeManager) ClearWorktrees() (bool, error) {
 		}
 	}
 
-	if err := os.Chdir(wm.GitRoot); err != nil {
-		return needsChdir, err
-	}
-
 	for _, worktreePath := range filteredWorktrees {
 		worktree := filepath.Base(worktreePath)
 		fmt.Printf("Removing worktree: %s\n", worktree)
 
-		if err := git.RunGitCommand("worktree", "remove", worktree, "--force"); err != nil {
+		if err := wm.removeWorktree(os.Stdout, worktreePath); err != nil {
 			fmt.Printf("Warning: failed to remove worktree %s: %v\n", worktree, err)
-			continue
-		}
-
-		if err := git.DeleteBranch(wm.GitRoot, worktree); err != nil {
-			fmt.Printf("Warning: failed to delete branch %s: %v\n", worktree, err)
 		}
 	}
 
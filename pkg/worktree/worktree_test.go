@@ -15,100 +15,100 @@
 package worktree
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/liamawhite/worktree/internal/gittest"
+	"github.com/liamawhite/worktree/pkg/git"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestWorktreeManager_GetWorktreeDirs(t *testing.T) {
-	tests := []struct {
-		name      string
-		setup     func(t *testing.T) string
-		wantCount int
-		wantErr   bool
-	}{
-		{
-			name: "finds worktree directories",
-			setup: func(t *testing.T) string {
-				tmpDir := t.TempDir()
-
-				// Create some worktree directories
-				dirs := []string{"main", "feature-branch", "review"}
-				for _, dir := range dirs {
-					if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
-						t.Fatal(err)
-					}
-				}
-
-				// Create hidden directory (should be ignored)
-				if err := os.MkdirAll(filepath.Join(tmpDir, ".hidden"), 0755); err != nil {
-					t.Fatal(err)
-				}
-
-				// Create a file (should be ignored)
-				if err := os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("test"), 0644); err != nil {
-					t.Fatal(err)
-				}
-
-				return tmpDir
-			},
-			wantCount: 3,
-			wantErr:   false,
-		},
-		{
-			name: "handles non-existent directory",
-			setup: func(t *testing.T) string {
-				return "/non/existent/path"
-			},
-			wantCount: 0,
-			wantErr:   true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gitRoot := tt.setup(t)
-			wm := &WorktreeManager{GitRoot: gitRoot}
-
-			got, err := wm.GetWorktreeDirs()
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				require.NoError(t, err)
-				assert.Len(t, got, tt.wantCount)
-			}
-		})
-	}
+	t.Run("finds worktrees recorded by git", func(t *testing.T) {
+		root := gittest.NewBareLayout(t, "feature-branch", "review")
+
+		// Hidden directories, plain directories and files are not worktrees
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "not-a-worktree"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "file.txt"), []byte("test"), 0644))
+
+		// Worktrees outside the root are found too
+		elsewhere := filepath.Join(t.TempDir(), "elsewhere")
+		gittest.Run(t, root, "worktree", "add", "-b", "elsewhere", elsewhere)
+		elsewhere, err := filepath.EvalSymlinks(elsewhere)
+		require.NoError(t, err)
+
+		wm := &WorktreeManager{GitRoot: root}
+		got, err := wm.GetWorktreeDirs()
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			elsewhere,
+			filepath.Join(root, "feature-branch"),
+			filepath.Join(root, "main"),
+			filepath.Join(root, "review"),
+		}, got)
+	})
+
+	t.Run("skips worktrees deleted without git", func(t *testing.T) {
+		root := gittest.NewBareLayout(t, "gone")
+		require.NoError(t, os.RemoveAll(filepath.Join(root, "gone")))
+
+		wm := &WorktreeManager{GitRoot: root}
+		got, err := wm.GetWorktreeDirs()
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(root, "main")}, got)
+	})
+
+	t.Run("handles non-existent directory", func(t *testing.T) {
+		wm := &WorktreeManager{GitRoot: "/non/existent/path"}
+		_, err := wm.GetWorktreeDirs()
+		assert.Error(t, err)
+	})
 }
 
 func TestWorktreeManager_GetFilteredWorktrees(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	// Create various worktree directories
-	dirs := []string{"main", "master", "review", "feature-1", "feature-2", "bugfix"}
-	for _, dir := range dirs {
-		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
-			t.Fatal(err)
-		}
-	}
-
-	wm := &WorktreeManager{GitRoot: tmpDir}
+	root := gittest.NewBareLayout(t, "master", "review", "feature-1", "feature-2", "bugfix")
+	wm := &WorktreeManager{GitRoot: root}
 
 	got, err := wm.GetFilteredWorktrees()
 	require.NoError(t, err)
 
 	// Should exclude main, master, review
-	expectedCount := 3 // feature-1, feature-2, bugfix
-	assert.Len(t, got, expectedCount)
-
-	// Check that main, master, review are not in results
-	for _, dir := range got {
-		name := filepath.Base(dir)
-		assert.NotContains(t, []string{"main", "master", "review"}, name)
-	}
+	assert.Equal(t, []string{
+		filepath.Join(root, "bugfix"),
+		filepath.Join(root, "feature-1"),
+		filepath.Join(root, "feature-2"),
+	}, got)
+}
+
+func TestWorktreeManager_CustomLayout(t *testing.T) {
+	root := gittest.NewBareLayout(t)
+	wtDir := t.TempDir()
+	wm := &WorktreeManager{GitRoot: root, PathTemplate: wtDir + "/{{.Repo}}/{{.Branch}}"}
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(originalDir) }()
+
+	require.NoError(t, wm.AddWorktree(io.Discard, "feature/login", "main"))
+	path := filepath.Join(wtDir, filepath.Base(root), "feature", "login")
+	assert.DirExists(t, path)
+
+	resolved, err := filepath.EvalSymlinks(path)
+	require.NoError(t, err)
+	dirs, err := wm.GetWorktreeDirs()
+	require.NoError(t, err)
+	assert.Contains(t, dirs, resolved)
+	assert.NoError(t, wm.CheckRemovable(resolved))
+
+	// The branch is deleted even though the directory is named after only part of it
+	_, err = wm.RemoveWorktree(io.Discard, resolved)
+	require.NoError(t, err)
+	assert.NoDirExists(t, path)
+	_, err = git.RunGitCommandOutputInDir(root, "rev-parse", "--verify", "refs/heads/feature/login")
+	assert.Error(t, err)
 }
 
 func TestWorktreeManager_CreateHooks(t *testing.T) {
@@ -190,3 +190,163 @@ func TestWorktreeManager_SwitchWorktree(t *testing.T) {
 	currentResolved, _ := filepath.EvalSymlinks(currentDir)
 	assert.Equal(t, expectedResolved, currentResolved)
 }
+
+func TestWorktreeManager_GetWorktreeInfo(t *testing.T) {
+	repo := gittest.InitRepo(t)
+	wm := &WorktreeManager{GitRoot: repo}
+
+	infos := wm.GetWorktreeInfo([]string{repo})
+	require.Len(t, infos, 1)
+	assert.Equal(t, "main", infos[0].Branch)
+	assert.False(t, infos[0].Dirty)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("changed\n"), 0644))
+	infos = wm.GetWorktreeInfo([]string{repo})
+	assert.True(t, infos[0].Dirty)
+}
+
+func TestWorktreeManager_GetWorktreeBranches(t *testing.T) {
+	repo := gittest.InitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("changed\n"), 0644))
+	wm := &WorktreeManager{GitRoot: repo}
+
+	infos := wm.GetWorktreeBranches([]string{repo})
+	require.Len(t, infos, 1)
+	assert.Equal(t, "main", infos[0].Branch)
+	assert.False(t, infos[0].Dirty, "the status is not loaded")
+	assert.True(t, wm.IsDirty(repo))
+}
+
+func TestWorktreeManager_BaseBranch(t *testing.T) {
+	root := gittest.NewBareLayout(t, "develop")
+	wm := &WorktreeManager{GitRoot: root}
+	assert.Equal(t, "main", wm.BaseBranch())
+
+	gittest.Run(t, root, "config", BaseBranchKey, "develop")
+	assert.Equal(t, "develop", wm.BaseBranch())
+
+	// Standard clones record it in their git directory
+	repo := gittest.InitRepo(t)
+	gittest.Run(t, repo, "config", BaseBranchKey, "trunk")
+	wm = &WorktreeManager{GitRoot: repo, Layout: LayoutStandard, GitDir: filepath.Join(repo, ".git")}
+	assert.Equal(t, "trunk", wm.BaseBranch())
+}
+
+func TestWorktreeManager_Preview(t *testing.T) {
+	repo := gittest.InitRepo(t)
+	wm := &WorktreeManager{GitRoot: repo}
+	assert.Equal(t, "main", wm.BaseBranch())
+
+	gittest.Run(t, repo, "checkout", "-b", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "feature.txt"), []byte("feature\n"), 0644))
+	gittest.Run(t, repo, "add", ".")
+	gittest.Run(t, repo, "commit", "-m", "add feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("changed\n"), 0644))
+
+	preview := wm.Preview(repo)
+	assert.Contains(t, preview, " M README.md")
+	assert.Contains(t, preview, "add feature")
+	assert.Contains(t, preview, "Changes against main")
+	assert.Contains(t, preview, "feature.txt")
+}
+
+func TestWorktreeManager_CheckRemovable(t *testing.T) {
+	root := gittest.NewBareLayout(t, "review", "feature")
+	wm := &WorktreeManager{GitRoot: root}
+
+	assert.NoError(t, wm.CheckRemovable(filepath.Join(root, "feature")))
+	assert.ErrorContains(t, wm.CheckRemovable(filepath.Join(root, "main")), "protected")
+	assert.ErrorContains(t, wm.CheckRemovable(filepath.Join(root, "review")), "protected")
+	assert.ErrorContains(t, wm.CheckRemovable(filepath.Join(root, "missing")), "not found")
+}
+
+func TestWorktreeManager_StandardClone(t *testing.T) {
+	parent := t.TempDir()
+	repo := filepath.Join(parent, "project")
+	require.NoError(t, os.Mkdir(repo, 0755))
+	gittest.Run(t, repo, "init", "--initial-branch=main")
+	gittest.Run(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repo))
+	defer func() { _ = os.Chdir(originalDir) }()
+
+	wm, err := NewWorktreeManager()
+	require.NoError(t, err)
+	root, err := filepath.EvalSymlinks(repo)
+	require.NoError(t, err)
+
+	assert.Equal(t, LayoutStandard, wm.Layout)
+	assert.Equal(t, root, wm.GitRoot)
+	assert.Equal(t, filepath.Join(filepath.Dir(root), "project.worktrees", "feature"), wm.WorktreePath("feature"))
+	assert.Equal(t, filepath.Join(root, ".git", "worktree-hooks"), wm.GetHooksDir())
+
+	// The main checkout is listed but can never be removed
+	dirs, err := wm.GetWorktreeDirs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{root}, dirs)
+	assert.ErrorContains(t, wm.CheckRemovable(root), "protected")
+
+	require.NoError(t, wm.AddWorktree(io.Discard, "feature", "main"))
+	feature := wm.WorktreePath("feature")
+	assert.DirExists(t, feature)
+	assert.NoDirExists(t, filepath.Join(root, "feature"))
+
+	dirs, err = wm.GetWorktreeDirs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{root, feature}, dirs)
+	filtered, err := wm.GetFilteredWorktrees()
+	require.NoError(t, err)
+	assert.Equal(t, []string{feature}, filtered)
+
+	// Commands run from a linked worktree resolve the same repository
+	require.NoError(t, os.Chdir(feature))
+	fromWorktree, err := NewWorktreeManager()
+	require.NoError(t, err)
+	assert.Equal(t, wm, fromWorktree)
+
+	_, err = wm.RemoveWorktree(io.Discard, feature)
+	require.NoError(t, err)
+	assert.NoDirExists(t, feature)
+}
+
+func TestWorktreeManager_BareLayout(t *testing.T) {
+	src := gittest.InitRepo(t)
+	root := t.TempDir()
+	gittest.Run(t, root, "clone", "--bare", src, ".bare")
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: ./.bare"), 0644))
+	gittest.Run(t, root, "worktree", "add", "main")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Join(root, "main")))
+	defer func() { _ = os.Chdir(originalDir) }()
+
+	wm, err := NewWorktreeManager()
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	assert.Equal(t, LayoutBare, wm.Layout)
+	assert.Equal(t, resolved, wm.GitRoot)
+	assert.Equal(t, filepath.Join(resolved, "feature"), wm.WorktreePath("feature"))
+	assert.Equal(t, filepath.Join(resolved, ".hooks"), wm.GetHooksDir())
+}
+
+func TestWorktreeManager_AddWorktree_SingleBranchClone(t *testing.T) {
+	src := gittest.InitRepo(t)
+	gittest.Run(t, src, "branch", "develop")
+
+	root := t.TempDir()
+	opts := git.CloneOptions{Depth: 1, SingleBranch: true, Branch: "main"}
+	require.NoError(t, git.CloneBareWithOptions("file://"+filepath.ToSlash(src), filepath.Join(root, ".bare"), io.Discard, opts))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: ./.bare"), 0644))
+
+	wm := &WorktreeManager{GitRoot: root}
+	assert.Equal(t, []string{"--depth=1"}, wm.CloneOptions().FetchArgs())
+
+	// develop wasn't cloned, so it is fetched before the worktree is created from it
+	require.NoError(t, wm.AddWorktree(io.Discard, "feature", "develop"))
+	assert.DirExists(t, filepath.Join(root, "feature"))
+	gittest.Run(t, root, "rev-parse", "--verify", "refs/heads/develop")
+}